@@ -0,0 +1,223 @@
+package main
+
+import (
+  "context"
+  "database/sql"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "net/http"
+  "slices"
+  "strings"
+  "time"
+  "unicode/utf8"
+
+  "github.com/google/uuid"
+
+  "github.com/j-wut/chirpy/internal/auth"
+  "github.com/j-wut/chirpy/internal/database"
+)
+
+const (
+  maxAPIKeyNameLength = 50
+
+  securityEventAPIKeyCreated = "api_key_created"
+  securityEventAPIKeyRevoked = "api_key_revoked"
+)
+
+// APIKey describes a key without revealing it. Key is only filled in on the
+// response that creates it; after that only the hint is kept.
+type APIKey struct {
+  ID          uuid.UUID  `json:"id"`
+  Name        string     `json:"name"`
+  Hint        string     `json:"hint"`
+  Scopes      []string   `json:"scopes"`
+  CreatedAt   time.Time  `json:"created_at"`
+  ExpiresAt   *time.Time `json:"expires_at"`
+  LastUsedAt  *time.Time `json:"last_used_at"`
+  Key         string     `json:"key,omitempty"`
+}
+
+type APIKeyRequest struct {
+  Name          string   `json:"name"`
+  Scopes        []string `json:"scopes"`
+  // zero never expires
+  ExpiresInDays int      `json:"expires_in_days"`
+}
+
+func DatabaseAPIKeyToReadable(key database.APIKey) APIKey {
+  return APIKey{
+    ID: key.ID,
+    Name: key.Name,
+    Hint: key.Prefix,
+    Scopes: key.Scopes,
+    CreatedAt: key.CreatedAt,
+    ExpiresAt: nullTimePtr(key.ExpiresAt),
+    LastUsedAt: nullTimePtr(key.LastUsedAt),
+  }
+}
+
+// lookupAPIKey finds the live key a caller presented, failing with
+// auth.ErrAPIKeyInvalid or auth.ErrAPIKeyExpired if it can't be used.
+func (cfg *apiConfig) lookupAPIKey(ctx context.Context, presented string) (database.APIKey, error) {
+  key, err := cfg.dbQueries.GetAPIKeyByHash(ctx, auth.HashAPIKey(presented))
+  if errors.Is(err, sql.ErrNoRows) {
+    return database.APIKey{}, auth.ErrAPIKeyInvalid
+  } else if err != nil {
+    return database.APIKey{}, err
+  }
+  if key.ExpiresAt.Valid && !key.ExpiresAt.Time.After(time.Now().UTC()) {
+    return database.APIKey{}, auth.ErrAPIKeyExpired
+  }
+
+  // last use is only informational, so it doesn't fail the request
+  if err = cfg.dbQueries.TouchAPIKey(ctx, key.ID); err != nil {
+    fmt.Printf("Error updating API key: %s", err)
+  }
+  return key, nil
+}
+
+func (cfg *apiConfig) getAPIKeys(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeAccount)
+  if !ok {
+    return
+  }
+
+  keys, err := cfg.dbQueries.GetAPIKeys(r.Context(), userID)
+  if err != nil {
+    fmt.Printf("Error retrieving API keys: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  readable := []APIKey{}
+  for _, key := range keys {
+    readable = append(readable, DatabaseAPIKeyToReadable(key))
+  }
+
+  resStr, err := json.Marshal(readable)
+  if err != nil {
+    fmt.Printf("Error Marshalling API keys: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
+
+// createAPIKey makes a key for bots and scripts. Keys are created with a
+// login rather than another key, and never get the account scope.
+func (cfg *apiConfig) createAPIKey(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeAccount)
+  if !ok {
+    return
+  }
+
+  decoder := json.NewDecoder(r.Body)
+  requestBody := APIKeyRequest{}
+  if err := decoder.Decode(&requestBody); err != nil {
+    fmt.Printf("Error decoding parameters: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  name := strings.TrimSpace(requestBody.Name)
+  if name == "" || utf8.RuneCountInString(name) > maxAPIKeyNameLength {
+    w.WriteHeader(400)
+    w.Write([]byte(fmt.Sprintf("API key name must be 1 to %d characters", maxAPIKeyNameLength)))
+    return
+  }
+  if len(requestBody.Scopes) == 0 {
+    w.WriteHeader(400)
+    w.Write([]byte("API key needs at least one scope"))
+    return
+  }
+  for _, scope := range requestBody.Scopes {
+    if !auth.ValidScope(scope) {
+      w.WriteHeader(400)
+      w.Write([]byte(fmt.Sprintf("Unknown scope %q, expected one of %s", scope, strings.Join(auth.APIKeyScopes, ", "))))
+      return
+    }
+  }
+  if requestBody.ExpiresInDays < 0 {
+    w.WriteHeader(400)
+    w.Write([]byte("expires_in_days can't be negative"))
+    return
+  }
+
+  scopes := slices.Clone(requestBody.Scopes)
+  slices.Sort(scopes)
+  scopes = slices.Compact(scopes)
+
+  expiresAt := sql.NullTime{}
+  if requestBody.ExpiresInDays > 0 {
+    expiresAt = sql.NullTime{Time: time.Now().UTC().AddDate(0, 0, requestBody.ExpiresInDays), Valid: true}
+  }
+
+  secret, hint, err := auth.MakeAPIKey()
+  if err != nil {
+    fmt.Printf("Error generating API key: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  key, err := cfg.dbQueries.CreateAPIKey(r.Context(), database.CreateAPIKeyParams{
+    UserID: userID,
+    Name: name,
+    Prefix: hint,
+    HashedKey: auth.HashAPIKey(secret),
+    Scopes: scopes,
+    ExpiresAt: expiresAt,
+  })
+  if err != nil {
+    fmt.Printf("Error creating API key: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  cfg.recordSecurityEvent(r, userID, securityEventAPIKeyCreated, fmt.Sprintf("API key %q (%s) created with scopes %s", key.Name, key.Prefix, strings.Join(key.Scopes, " ")))
+
+  readable := DatabaseAPIKeyToReadable(key)
+  readable.Key = secret
+  resStr, err := json.Marshal(readable)
+  if err != nil {
+    fmt.Printf("Error Marshalling API key: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(201)
+  w.Write(resStr)
+  return
+}
+
+func (cfg *apiConfig) revokeAPIKey(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeAccount)
+  if !ok {
+    return
+  }
+
+  keyID, err := uuid.Parse(r.PathValue("id"))
+  if err != nil {
+    w.WriteHeader(404)
+    return
+  }
+
+  revoked, err := cfg.dbQueries.RevokeAPIKey(r.Context(), database.RevokeAPIKeyParams{ID: keyID, UserID: userID})
+  if err != nil {
+    fmt.Printf("Error revoking API key: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  if revoked == 0 {
+    w.WriteHeader(404)
+    return
+  }
+  cfg.recordSecurityEvent(r, userID, securityEventAPIKeyRevoked, fmt.Sprintf("API key %s revoked", keyID))
+
+  w.WriteHeader(204)
+  return
+}
@@ -0,0 +1,405 @@
+package main
+
+import (
+  "context"
+  "database/sql"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "net/http"
+  "strings"
+  "time"
+  "unicode/utf8"
+
+  "github.com/google/uuid"
+
+  "github.com/j-wut/chirpy/internal/auth"
+  "github.com/j-wut/chirpy/internal/database"
+  "github.com/j-wut/chirpy/internal/entities"
+)
+
+const maxMutedKeywordLength = 100
+
+var errBlocked = errors.New("You cannot interact with this user")
+
+type BlockEntry struct {
+  UserID    uuid.UUID `json:"user_id"`
+  CreatedAt time.Time `json:"created_at"`
+}
+
+type MutedKeyword struct {
+  Keyword   string    `json:"keyword"`
+  CreatedAt time.Time `json:"created_at"`
+}
+
+type MutedKeywordRequest struct {
+  Keyword string `json:"keyword"`
+}
+
+// blockedEitherWay reports whether either user has blocked the other.
+func (cfg *apiConfig) blockedEitherWay(ctx context.Context, a, b uuid.UUID) (bool, error) {
+  if a == b {
+    return false, nil
+  }
+  return cfg.dbQueries.IsBlockedEitherWay(ctx, database.IsBlockedEitherWayParams{UserA: a, UserB: b})
+}
+
+// blockedUsers returns everyone viewer has blocked or been blocked by.
+// Anonymous viewers have no blocks.
+func (cfg *apiConfig) blockedUsers(ctx context.Context, viewer uuid.NullUUID) (map[uuid.UUID]bool, error) {
+  blocked := map[uuid.UUID]bool{}
+  if !viewer.Valid {
+    return blocked, nil
+  }
+  ids, err := cfg.dbQueries.GetBlockedEitherWay(ctx, viewer.UUID)
+  if err != nil {
+    return nil, err
+  }
+  for _, id := range ids {
+    blocked[id] = true
+  }
+  return blocked, nil
+}
+
+// mentionsBlocked reports whether body mentions anyone on the other side of
+// a block from userID.
+func (cfg *apiConfig) mentionsBlocked(ctx context.Context, userID uuid.UUID, body string) (bool, error) {
+  mentions := entities.Mentions(body)
+  if len(mentions) == 0 {
+    return false, nil
+  }
+
+  usernames := []string{}
+  for _, mention := range mentions {
+    usernames = append(usernames, strings.ToLower(mention.Username))
+  }
+  users, err := cfg.dbQueries.GetUsersByUsernames(ctx, usernames)
+  if err != nil {
+    return false, err
+  }
+
+  blocked, err := cfg.blockedUsers(ctx, uuid.NullUUID{UUID: userID, Valid: true})
+  if err != nil {
+    return false, err
+  }
+  for _, user := range users {
+    if blocked[user.ID] {
+      return true, nil
+    }
+  }
+  return false, nil
+}
+
+func (cfg *apiConfig) block(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeSocialWrite)
+  if !ok {
+    return
+  }
+
+  blockedID, err := uuid.Parse(r.PathValue("id"))
+  if err != nil {
+    w.WriteHeader(404)
+    return
+  }
+
+  if blockedID == userID {
+    w.WriteHeader(400)
+    w.Write([]byte("Cannot block yourself"))
+    return
+  }
+
+  if _, err = cfg.dbQueries.GetUserByID(r.Context(), blockedID); errors.Is(err, sql.ErrNoRows) {
+    w.WriteHeader(404)
+    return
+  } else if err != nil {
+    fmt.Printf("Error retrieving user: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  // a block cuts both users off, so neither keeps following the other
+  tx, err := cfg.db.BeginTx(r.Context(), nil)
+  if err != nil {
+    fmt.Printf("Error starting transaction: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  defer tx.Rollback()
+  qtx := cfg.dbQueries.WithTx(tx)
+
+  if err = qtx.BlockUser(r.Context(), database.BlockUserParams{BlockerID: userID, BlockedID: blockedID}); err != nil {
+    fmt.Printf("Error saving block: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  if err = qtx.DeleteFollowsBetween(r.Context(), database.DeleteFollowsBetweenParams{UserA: userID, UserB: blockedID}); err != nil {
+    fmt.Printf("Error deleting follows: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  if err = qtx.DeleteFollowRequestsBetween(r.Context(), database.DeleteFollowRequestsBetweenParams{UserA: userID, UserB: blockedID}); err != nil {
+    fmt.Printf("Error deleting follow requests: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  if err = tx.Commit(); err != nil {
+    fmt.Printf("Error committing block: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.WriteHeader(204)
+  return
+}
+
+func (cfg *apiConfig) unblock(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeSocialWrite)
+  if !ok {
+    return
+  }
+
+  blockedID, err := uuid.Parse(r.PathValue("id"))
+  if err != nil {
+    w.WriteHeader(404)
+    return
+  }
+
+  if _, err = cfg.dbQueries.UnblockUser(r.Context(), database.UnblockUserParams{BlockerID: userID, BlockedID: blockedID}); err != nil {
+    fmt.Printf("Error deleting block: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.WriteHeader(204)
+  return
+}
+
+func (cfg *apiConfig) getBlocks(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeSocialRead)
+  if !ok {
+    return
+  }
+
+  blocks, err := cfg.dbQueries.GetBlocks(r.Context(), userID)
+  if err != nil {
+    fmt.Printf("Error retrieving blocks: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  entries := []BlockEntry{}
+  for _, b := range blocks {
+    entries = append(entries, BlockEntry{b.BlockedID, b.CreatedAt})
+  }
+
+  resStr, err := json.Marshal(entries)
+  if err != nil {
+    fmt.Printf("Error Marshalling blocks: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
+
+func (cfg *apiConfig) mute(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeSocialWrite)
+  if !ok {
+    return
+  }
+
+  mutedID, err := uuid.Parse(r.PathValue("id"))
+  if err != nil {
+    w.WriteHeader(404)
+    return
+  }
+
+  if mutedID == userID {
+    w.WriteHeader(400)
+    w.Write([]byte("Cannot mute yourself"))
+    return
+  }
+
+  if _, err = cfg.dbQueries.GetUserByID(r.Context(), mutedID); errors.Is(err, sql.ErrNoRows) {
+    w.WriteHeader(404)
+    return
+  } else if err != nil {
+    fmt.Printf("Error retrieving user: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  if err = cfg.dbQueries.MuteUser(r.Context(), database.MuteUserParams{MuterID: userID, MutedID: mutedID}); err != nil {
+    fmt.Printf("Error saving mute: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.WriteHeader(204)
+  return
+}
+
+func (cfg *apiConfig) unmute(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeSocialWrite)
+  if !ok {
+    return
+  }
+
+  mutedID, err := uuid.Parse(r.PathValue("id"))
+  if err != nil {
+    w.WriteHeader(404)
+    return
+  }
+
+  if _, err = cfg.dbQueries.UnmuteUser(r.Context(), database.UnmuteUserParams{MuterID: userID, MutedID: mutedID}); err != nil {
+    fmt.Printf("Error deleting mute: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.WriteHeader(204)
+  return
+}
+
+func (cfg *apiConfig) getMutes(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeSocialRead)
+  if !ok {
+    return
+  }
+
+  mutes, err := cfg.dbQueries.GetMutes(r.Context(), userID)
+  if err != nil {
+    fmt.Printf("Error retrieving mutes: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  entries := []BlockEntry{}
+  for _, m := range mutes {
+    entries = append(entries, BlockEntry{m.MutedID, m.CreatedAt})
+  }
+
+  resStr, err := json.Marshal(entries)
+  if err != nil {
+    fmt.Printf("Error Marshalling mutes: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
+
+// normalizeMutedKeyword lowercases and trims a keyword, returning "" if
+// nothing usable is left.
+func normalizeMutedKeyword(keyword string) string {
+  keyword = strings.ToLower(strings.Join(strings.Fields(keyword), " "))
+  if utf8.RuneCountInString(keyword) > maxMutedKeywordLength {
+    return ""
+  }
+  return keyword
+}
+
+func (cfg *apiConfig) addMutedKeyword(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeSocialWrite)
+  if !ok {
+    return
+  }
+
+  decoder := json.NewDecoder(r.Body)
+  requestBody := MutedKeywordRequest{}
+  if err := decoder.Decode(&requestBody); err != nil {
+    fmt.Printf("Error decoding parameters: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  keyword := normalizeMutedKeyword(requestBody.Keyword)
+  if keyword == "" {
+    w.WriteHeader(400)
+    w.Write([]byte(fmt.Sprintf("Keyword must be between 1 and %d characters", maxMutedKeywordLength)))
+    return
+  }
+
+  // keywords are matched as search phrases, and one made only of stop words
+  // like "the" would be saved but never hide anything
+  canMatch, err := cfg.dbQueries.KeywordCanMatch(r.Context(), keyword)
+  if err != nil {
+    fmt.Printf("Error checking muted keyword: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  if !canMatch {
+    w.WriteHeader(400)
+    w.Write([]byte("Keyword is too common to mute"))
+    return
+  }
+
+  err = cfg.dbQueries.AddMutedKeyword(r.Context(), database.AddMutedKeywordParams{UserID: userID, Keyword: keyword})
+  if err != nil {
+    fmt.Printf("Error saving muted keyword: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.WriteHeader(204)
+  return
+}
+
+func (cfg *apiConfig) removeMutedKeyword(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeSocialWrite)
+  if !ok {
+    return
+  }
+
+  keyword := normalizeMutedKeyword(r.PathValue("keyword"))
+  removed, err := cfg.dbQueries.RemoveMutedKeyword(r.Context(), database.RemoveMutedKeywordParams{UserID: userID, Keyword: keyword})
+  if err != nil {
+    fmt.Printf("Error deleting muted keyword: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  if removed == 0 {
+    w.WriteHeader(404)
+    return
+  }
+
+  w.WriteHeader(204)
+  return
+}
+
+func (cfg *apiConfig) getMutedKeywords(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeSocialRead)
+  if !ok {
+    return
+  }
+
+  keywords, err := cfg.dbQueries.GetMutedKeywords(r.Context(), userID)
+  if err != nil {
+    fmt.Printf("Error retrieving muted keywords: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  entries := []MutedKeyword{}
+  for _, k := range keywords {
+    entries = append(entries, MutedKeyword{k.Keyword, k.CreatedAt})
+  }
+
+  resStr, err := json.Marshal(entries)
+  if err != nil {
+    fmt.Printf("Error Marshalling muted keywords: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+  "context"
+  "database/sql"
+  "errors"
+  "fmt"
+
+  "github.com/j-wut/chirpy/internal/auth"
+  "github.com/j-wut/chirpy/internal/database"
+)
+
+const usage = `usage: chirpy [command]
+
+With no command, chirpy serves the API.
+
+commands:
+  promote-admin <email>   make the first admin; later admins are
+                          promoted through PUT /admin/users/{id}/role`
+
+// runCommand runs one of the admin subcommands instead of the server.
+func runCommand(ctx context.Context, q *database.Queries, args []string) error {
+  switch args[0] {
+  case "promote-admin":
+    if len(args) != 2 {
+      return errors.New(usage)
+    }
+    return promoteFirstAdmin(ctx, q, args[1])
+  default:
+    return errors.New(usage)
+  }
+}
+
+// promoteFirstAdmin bootstraps the first admin. It refuses once one exists
+// so the command can't be used to sidestep the audited admin API.
+func promoteFirstAdmin(ctx context.Context, q *database.Queries, email string) error {
+  admins, err := q.CountUsersWithRole(ctx, auth.RoleAdmin)
+  if err != nil {
+    return err
+  }
+  if admins > 0 {
+    return errors.New("an admin already exists; promote users through PUT /admin/users/{id}/role")
+  }
+
+  user, err := q.GetUser(ctx, email)
+  if errors.Is(err, sql.ErrNoRows) {
+    return fmt.Errorf("no user with email %s", email)
+  } else if err != nil {
+    return err
+  }
+
+  if _, err = q.SetUserRole(ctx, database.SetUserRoleParams{ID: user.ID, Role: auth.RoleAdmin}); err != nil {
+    return err
+  }
+  fmt.Printf("Promoted %s (%s) to admin\n", user.Username, user.ID)
+  return nil
+}
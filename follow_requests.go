@@ -0,0 +1,136 @@
+package main
+
+import (
+  "context"
+  "encoding/json"
+  "fmt"
+  "net/http"
+  "time"
+
+  "github.com/google/uuid"
+
+  "github.com/j-wut/chirpy/internal/auth"
+  "github.com/j-wut/chirpy/internal/database"
+)
+
+type FollowRequestEntry struct {
+  UserID      uuid.UUID `json:"user_id"`
+  RequestedAt time.Time `json:"requested_at"`
+}
+
+// hiddenAuthors returns the protected users among authorIDs whose chirps
+// viewer isn't allowed to see, that is everyone viewer neither is nor follows.
+func (cfg *apiConfig) hiddenAuthors(ctx context.Context, viewer uuid.NullUUID, authorIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+  hidden := map[uuid.UUID]bool{}
+  if len(authorIDs) == 0 {
+    return hidden, nil
+  }
+  ids, err := cfg.dbQueries.GetHiddenAuthors(ctx, database.GetHiddenAuthorsParams{UserIds: authorIDs, ViewerID: viewer})
+  if err != nil {
+    return nil, err
+  }
+  for _, id := range ids {
+    hidden[id] = true
+  }
+  return hidden, nil
+}
+
+// authorVisibleTo reports whether viewer may see chirps written by authorID.
+func (cfg *apiConfig) authorVisibleTo(ctx context.Context, authorID uuid.UUID, viewer uuid.NullUUID) (bool, error) {
+  hidden, err := cfg.hiddenAuthors(ctx, viewer, []uuid.UUID{authorID})
+  if err != nil {
+    return false, err
+  }
+  return !hidden[authorID], nil
+}
+
+func (cfg *apiConfig) getFollowRequests(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeSocialRead)
+  if !ok {
+    return
+  }
+
+  requests, err := cfg.dbQueries.GetFollowRequests(r.Context(), userID)
+  if err != nil {
+    fmt.Printf("Error retrieving follow requests: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  entries := []FollowRequestEntry{}
+  for _, req := range requests {
+    entries = append(entries, FollowRequestEntry{req.RequesterID, req.CreatedAt})
+  }
+
+  resStr, err := json.Marshal(entries)
+  if err != nil {
+    fmt.Printf("Error Marshalling follow requests: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
+
+func (cfg *apiConfig) approveFollowRequest(w http.ResponseWriter, r *http.Request) {
+  cfg.answerFollowRequest(w, r, true)
+}
+
+func (cfg *apiConfig) denyFollowRequest(w http.ResponseWriter, r *http.Request) {
+  cfg.answerFollowRequest(w, r, false)
+}
+
+// answerFollowRequest settles a pending request from the user in the path to
+// follow the caller. Approving it turns it into a follow.
+func (cfg *apiConfig) answerFollowRequest(w http.ResponseWriter, r *http.Request, approve bool) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeSocialWrite)
+  if !ok {
+    return
+  }
+
+  requesterID, err := uuid.Parse(r.PathValue("id"))
+  if err != nil {
+    w.WriteHeader(404)
+    return
+  }
+
+  tx, err := cfg.db.BeginTx(r.Context(), nil)
+  if err != nil {
+    fmt.Printf("Error starting transaction: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  defer tx.Rollback()
+  qtx := cfg.dbQueries.WithTx(tx)
+
+  removed, err := qtx.DeleteFollowRequest(r.Context(), database.DeleteFollowRequestParams{RequesterID: requesterID, TargetID: userID})
+  if err != nil {
+    fmt.Printf("Error deleting follow request: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  if removed == 0 {
+    w.WriteHeader(404)
+    return
+  }
+
+  if approve {
+    if err = qtx.CreateFollow(r.Context(), database.CreateFollowParams{FollowerID: requesterID, FolloweeID: userID}); err != nil {
+      fmt.Printf("Error saving follow: %s", err)
+      w.WriteHeader(500)
+      return
+    }
+  }
+
+  if err = tx.Commit(); err != nil {
+    fmt.Printf("Error committing follow request: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.WriteHeader(204)
+  return
+}
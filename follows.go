@@ -0,0 +1,238 @@
+package main
+
+import (
+  "database/sql"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "net/http"
+  "time"
+
+  "github.com/google/uuid"
+
+  "github.com/j-wut/chirpy/internal/auth"
+  "github.com/j-wut/chirpy/internal/database"
+  "github.com/j-wut/chirpy/internal/pagination"
+)
+
+type FollowEntry struct {
+  UserID      uuid.UUID `json:"user_id"`
+  FollowedAt  time.Time `json:"followed_at"`
+}
+
+func (cfg *apiConfig) follow(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeSocialWrite)
+  if !ok {
+    return
+  }
+
+  followeeID, err := uuid.Parse(r.PathValue("id"))
+  if err != nil {
+    w.WriteHeader(404)
+    return
+  }
+
+  if followeeID == userID {
+    w.WriteHeader(400)
+    w.Write([]byte("Cannot follow yourself"))
+    return
+  }
+
+  followee, err := cfg.dbQueries.GetUserByID(r.Context(), followeeID)
+  if errors.Is(err, sql.ErrNoRows) {
+    w.WriteHeader(404)
+    return
+  } else if err != nil {
+    fmt.Printf("Error retrieving user: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  if blocked, err := cfg.blockedEitherWay(r.Context(), userID, followeeID); err != nil {
+    fmt.Printf("Error checking blocks: %s", err)
+    w.WriteHeader(500)
+    return
+  } else if blocked {
+    w.WriteHeader(403)
+    w.Write([]byte(errBlocked.Error()))
+    return
+  }
+
+  // protected accounts approve their followers, so ask instead
+  if followee.Protected {
+    visible, err := cfg.authorVisibleTo(r.Context(), followeeID, uuid.NullUUID{UUID: userID, Valid: true})
+    if err != nil {
+      fmt.Printf("Error checking follow: %s", err)
+      w.WriteHeader(500)
+      return
+    }
+    if !visible {
+      err = cfg.dbQueries.CreateFollowRequest(r.Context(), database.CreateFollowRequestParams{RequesterID: userID, TargetID: followeeID})
+      if err != nil {
+        fmt.Printf("Error saving follow request: %s", err)
+        w.WriteHeader(500)
+        return
+      }
+      w.WriteHeader(202)
+      return
+    }
+  }
+
+  err = cfg.dbQueries.CreateFollow(r.Context(), database.CreateFollowParams{FollowerID: userID, FolloweeID: followeeID})
+  if err != nil {
+    fmt.Printf("Error saving follow: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.WriteHeader(204)
+  return
+}
+
+func (cfg *apiConfig) unfollow(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeSocialWrite)
+  if !ok {
+    return
+  }
+
+  followeeID, err := uuid.Parse(r.PathValue("id"))
+  if err != nil {
+    w.WriteHeader(404)
+    return
+  }
+
+  err = cfg.dbQueries.DeleteFollow(r.Context(), database.DeleteFollowParams{FollowerID: userID, FolloweeID: followeeID})
+  if err != nil {
+    fmt.Printf("Error deleting follow: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  // unfollowing also withdraws a request that hasn't been answered yet
+  if _, err = cfg.dbQueries.DeleteFollowRequest(r.Context(), database.DeleteFollowRequestParams{RequesterID: userID, TargetID: followeeID}); err != nil {
+    fmt.Printf("Error deleting follow request: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.WriteHeader(204)
+  return
+}
+
+func (cfg *apiConfig) getFollowers(w http.ResponseWriter, r *http.Request) {
+  cfg.listFollows(w, r, true)
+}
+
+func (cfg *apiConfig) getFollowing(w http.ResponseWriter, r *http.Request) {
+  cfg.listFollows(w, r, false)
+}
+
+func (cfg *apiConfig) listFollows(w http.ResponseWriter, r *http.Request, followers bool) {
+  userID, err := uuid.Parse(r.PathValue("id"))
+  if err != nil {
+    w.WriteHeader(404)
+    return
+  }
+
+  if _, err = cfg.dbQueries.GetUserByID(r.Context(), userID); errors.Is(err, sql.ErrNoRows) {
+    w.WriteHeader(404)
+    return
+  } else if err != nil {
+    fmt.Printf("Error retrieving user: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  var follows []database.Follow
+  if followers {
+    follows, err = cfg.dbQueries.GetFollowers(r.Context(), userID)
+  } else {
+    follows, err = cfg.dbQueries.GetFollowing(r.Context(), userID)
+  }
+  if err != nil {
+    fmt.Printf("Error retrieving follows: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  entries := []FollowEntry{}
+  for _, f := range follows {
+    if followers {
+      entries = append(entries, FollowEntry{f.FollowerID, f.CreatedAt})
+    } else {
+      entries = append(entries, FollowEntry{f.FolloweeID, f.CreatedAt})
+    }
+  }
+
+  resStr, err := json.Marshal(entries)
+  if err != nil {
+    fmt.Printf("Error Marshalling follows: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
+
+// getTimeline returns chirps from everyone the caller follows, newest first.
+func (cfg *apiConfig) getTimeline(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsRead)
+  if !ok {
+    return
+  }
+
+  query := r.URL.Query()
+  limit, err := pagination.ParseLimit(query.Get("limit"))
+  if err != nil {
+    w.WriteHeader(400)
+    w.Write([]byte(err.Error()))
+    return
+  }
+
+  params := database.GetTimelineChirpsParams{UserID: userID, RowLimit: limit + 1}
+  if after := query.Get("after"); after != "" {
+    cursor, err := pagination.DecodeCursor(after)
+    if err != nil {
+      w.WriteHeader(400)
+      w.Write([]byte(err.Error()))
+      return
+    }
+    params.CreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+    params.ID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+  }
+
+  chirps, err := cfg.dbQueries.GetTimelineChirps(r.Context(), params)
+  if err != nil {
+    fmt.Printf("Error retrieving timeline: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  page := ChirpPage{}
+  if len(chirps) > int(limit) {
+    chirps = chirps[:limit]
+    page.NextCursor = chirpCursor(chirps[len(chirps)-1])
+  }
+  page.Chirps = DatabaseChirpsToReadable(chirps)
+
+  if err = cfg.decorateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, page.Chirps); err != nil {
+    fmt.Printf("Error decorating chirps: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  resStr, err := json.Marshal(page)
+  if err != nil {
+    fmt.Printf("Error Marshalling chirps: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+  "crypto/rand"
+  "crypto/sha256"
+  "encoding/hex"
+  "errors"
+  "strings"
+)
+
+// APIKeyPrefix starts every API key, so they can't be mistaken for JWTs and
+// are easy to spot if one leaks.
+const APIKeyPrefix = "chirpy_"
+
+var (
+  ErrAPIKeyInvalid = errors.New("API key is unknown or revoked")
+  ErrAPIKeyExpired = errors.New("API key has expired")
+)
+
+// apiKeyHintLength is how much of a key is kept in the clear to tell keys
+// apart, prefix included.
+const apiKeyHintLength = len(APIKeyPrefix) + 8
+
+// MakeAPIKey returns a new random key and the hint that can be shown for it
+// later. Only the key's hash should be stored.
+func MakeAPIKey() (key, hint string, err error) {
+  b := make([]byte, 32)
+  if _, err = rand.Read(b); err != nil {
+    return "", "", err
+  }
+  key = APIKeyPrefix + hex.EncodeToString(b)
+  return key, key[:apiKeyHintLength], nil
+}
+
+// HashAPIKey hashes a key for storage and lookup. Keys are random enough
+// that a fast unsalted hash is safe, unlike passwords.
+func HashAPIKey(key string) string {
+  sum := sha256.Sum256([]byte(key))
+  return hex.EncodeToString(sum[:])
+}
+
+// IsAPIKey reports whether a bearer token is an API key rather than a JWT.
+func IsAPIKey(token string) bool {
+  return strings.HasPrefix(token, APIKeyPrefix)
+}
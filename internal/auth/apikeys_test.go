@@ -0,0 +1,57 @@
+package auth
+
+import (
+  "net/http"
+  "strings"
+  "testing"
+)
+
+func TestAPIKey(t *testing.T) {
+  key, hint, err := MakeAPIKey()
+  if err != nil {
+    t.Fatalf("error generating API key: %v", err)
+  }
+  if !IsAPIKey(key) {
+    t.Errorf("expected %q to be recognised as an API key", key)
+  }
+  if !strings.HasPrefix(key, hint) || hint == key {
+    t.Errorf("expected hint %q to be a strict prefix of the key", hint)
+  }
+
+  other, _, err := MakeAPIKey()
+  if err != nil {
+    t.Fatalf("error generating API key: %v", err)
+  }
+  if HashAPIKey(key) != HashAPIKey(key) {
+    t.Errorf("expected hashing a key to be deterministic")
+  }
+  if HashAPIKey(key) == HashAPIKey(other) {
+    t.Errorf("expected different keys to hash differently")
+  }
+  if IsAPIKey("eyJhbGciOiJFZERTQSJ9.e30.sig") {
+    t.Errorf("expected a JWT not to be recognised as an API key")
+  }
+}
+
+func TestGetBearerToken(t *testing.T) {
+  cases := []struct {
+    header   string
+    expected string
+  }{
+    {"Bearer abc.def.ghi", "abc.def.ghi"},
+    {"Bearer chirpy_0123", "chirpy_0123"},
+    {"ApiKey chirpy_0123", "chirpy_0123"},
+  }
+  for _, c := range cases {
+    headers := http.Header{}
+    headers.Set("Authorization", c.header)
+    res, err := GetBearerToken(headers)
+    if err != nil || res != c.expected {
+      t.Errorf("incorrect token for %q, expected %q, got %q (%v)", c.header, c.expected, res, err)
+    }
+  }
+
+  if _, err := GetBearerToken(http.Header{}); err != ErrNoAuthHeader {
+    t.Errorf("expected ErrNoAuthHeader without a header, got %v", err)
+  }
+}
@@ -0,0 +1,304 @@
+package auth
+
+import (
+  "crypto"
+  "crypto/aes"
+  "crypto/cipher"
+  "crypto/ed25519"
+  "crypto/rand"
+  "crypto/rsa"
+  "crypto/x509"
+  "encoding/base64"
+  "encoding/hex"
+  "errors"
+  "fmt"
+  "math/big"
+  "slices"
+  "sync"
+  "time"
+
+  "github.com/golang-jwt/jwt/v5"
+)
+
+const (
+  AlgEdDSA = "EdDSA"
+  AlgRS256 = "RS256"
+
+  rsaKeyBits = 2048
+
+  // private keys are sealed with AES-256-GCM
+  keyEncryptionKeySize = 32
+)
+
+var (
+  ErrNoSigningKey  = errors.New("no signing key is active")
+  ErrUnknownKey    = errors.New("token was signed with an unknown key")
+  ErrSealedKey     = errors.New("sealed key could not be opened")
+)
+
+// ValidAlgorithm reports whether alg is a signing algorithm Chirpy can use.
+func ValidAlgorithm(alg string) bool {
+  return alg == AlgEdDSA || alg == AlgRS256
+}
+
+// SigningKey is one key in a Keyring. It signs tokens from ActiveAt on, and
+// verifies them until ExpiresAt. A zero ExpiresAt never expires.
+type SigningKey struct {
+  ID        string
+  Algorithm string
+  Private   crypto.Signer
+  ActiveAt  time.Time
+  ExpiresAt time.Time
+}
+
+// GenerateSigningKey makes a new key for alg with a random ID.
+func GenerateSigningKey(alg string) (*SigningKey, error) {
+  var private crypto.Signer
+  var err error
+  switch alg {
+  case AlgEdDSA:
+    _, private, err = ed25519.GenerateKey(rand.Reader)
+  case AlgRS256:
+    private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+  default:
+    return nil, fmt.Errorf("unsupported signing algorithm %q", alg)
+  }
+  if err != nil {
+    return nil, err
+  }
+
+  id := make([]byte, 8)
+  if _, err = rand.Read(id); err != nil {
+    return nil, err
+  }
+  return &SigningKey{ID: hex.EncodeToString(id), Algorithm: alg, Private: private}, nil
+}
+
+// ParseSigningKey rebuilds a key saved with MarshalPrivateKey.
+func ParseSigningKey(id, alg string, der []byte) (*SigningKey, error) {
+  parsed, err := x509.ParsePKCS8PrivateKey(der)
+  if err != nil {
+    return nil, err
+  }
+
+  var private crypto.Signer
+  switch k := parsed.(type) {
+  case ed25519.PrivateKey:
+    if alg != AlgEdDSA {
+      return nil, fmt.Errorf("key %s is ed25519 but marked %s", id, alg)
+    }
+    private = k
+  case *rsa.PrivateKey:
+    if alg != AlgRS256 {
+      return nil, fmt.Errorf("key %s is RSA but marked %s", id, alg)
+    }
+    private = k
+  default:
+    return nil, fmt.Errorf("key %s has unsupported type %T", id, parsed)
+  }
+  return &SigningKey{ID: id, Algorithm: alg, Private: private}, nil
+}
+
+// MarshalPrivateKey encodes the private key as PKCS #8 DER.
+func (k *SigningKey) MarshalPrivateKey() ([]byte, error) {
+  return x509.MarshalPKCS8PrivateKey(k.Private)
+}
+
+// ParseKeyEncryptionKey decodes the base64 key that private keys are sealed
+// with at rest.
+func ParseKeyEncryptionKey(s string) ([]byte, error) {
+  kek, err := base64.StdEncoding.DecodeString(s)
+  if err != nil {
+    return nil, err
+  }
+  if len(kek) != keyEncryptionKeySize {
+    return nil, fmt.Errorf("key encryption key must be %d bytes, got %d", keyEncryptionKeySize, len(kek))
+  }
+  return kek, nil
+}
+
+func keyEncryption(kek []byte) (cipher.AEAD, error) {
+  block, err := aes.NewCipher(kek)
+  if err != nil {
+    return nil, err
+  }
+  return cipher.NewGCM(block)
+}
+
+// SealPrivateKey encrypts the private key under kek for storage. The key ID
+// is authenticated along with it, so a sealed key only opens as itself.
+func (k *SigningKey) SealPrivateKey(kek []byte) ([]byte, error) {
+  der, err := k.MarshalPrivateKey()
+  if err != nil {
+    return nil, err
+  }
+  aead, err := keyEncryption(kek)
+  if err != nil {
+    return nil, err
+  }
+  nonce := make([]byte, aead.NonceSize())
+  if _, err = rand.Read(nonce); err != nil {
+    return nil, err
+  }
+  return aead.Seal(nonce, nonce, der, []byte(k.ID)), nil
+}
+
+// OpenSigningKey rebuilds a key saved with SealPrivateKey.
+func OpenSigningKey(id, alg string, sealed, kek []byte) (*SigningKey, error) {
+  aead, err := keyEncryption(kek)
+  if err != nil {
+    return nil, err
+  }
+  if len(sealed) < aead.NonceSize() {
+    return nil, ErrSealedKey
+  }
+  nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+  der, err := aead.Open(nil, nonce, ciphertext, []byte(id))
+  if err != nil {
+    return nil, fmt.Errorf("key %s: %w", id, ErrSealedKey)
+  }
+  return ParseSigningKey(id, alg, der)
+}
+
+func (k *SigningKey) method() jwt.SigningMethod {
+  if k.Algorithm == AlgRS256 {
+    return jwt.SigningMethodRS256
+  }
+  return jwt.SigningMethodEdDSA
+}
+
+func (k *SigningKey) expired(now time.Time) bool {
+  return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
+
+// Keyring holds the keys tokens are signed and verified with. It is safe for
+// concurrent use, and its keys can be swapped out while it is in use.
+type Keyring struct {
+  mu    sync.RWMutex
+  keys  []*SigningKey
+}
+
+func NewKeyring(keys ...*SigningKey) *Keyring {
+  kr := &Keyring{}
+  kr.Replace(keys)
+  return kr
+}
+
+// Replace swaps in a new set of keys.
+func (kr *Keyring) Replace(keys []*SigningKey) {
+  sorted := slices.Clone(keys)
+  slices.SortFunc(sorted, func(a, b *SigningKey) int {
+    return a.ActiveAt.Compare(b.ActiveAt)
+  })
+
+  kr.mu.Lock()
+  defer kr.mu.Unlock()
+  kr.keys = sorted
+}
+
+// SigningKey returns the most recently activated key that hasn't expired.
+func (kr *Keyring) SigningKey(now time.Time) (*SigningKey, error) {
+  kr.mu.RLock()
+  defer kr.mu.RUnlock()
+
+  for i := len(kr.keys) - 1; i >= 0; i-- {
+    key := kr.keys[i]
+    if !key.ActiveAt.After(now) && !key.expired(now) {
+      return key, nil
+    }
+  }
+  return nil, ErrNoSigningKey
+}
+
+// VerificationKey returns the unexpired key with the given ID. Keys that
+// aren't active yet are included, since they are published ahead of use.
+func (kr *Keyring) VerificationKey(id string, now time.Time) (*SigningKey, error) {
+  kr.mu.RLock()
+  defer kr.mu.RUnlock()
+
+  for _, key := range kr.keys {
+    if key.ID == id && !key.expired(now) {
+      return key, nil
+    }
+  }
+  return nil, ErrUnknownKey
+}
+
+// Sign signs claims with the current signing key and names it in the kid
+// header.
+func (kr *Keyring) Sign(claims jwt.Claims) (string, error) {
+  key, err := kr.SigningKey(time.Now())
+  if err != nil {
+    return "", err
+  }
+  token := jwt.NewWithClaims(key.method(), claims)
+  token.Header["kid"] = key.ID
+  return token.SignedString(key.Private)
+}
+
+// keyfunc finds the public key for a token being parsed. The algorithm in the
+// header is pinned: it has to be one Chirpy signs with, and the one the key
+// was made for.
+func (kr *Keyring) keyfunc(token *jwt.Token) (any, error) {
+  if !ValidAlgorithm(token.Method.Alg()) {
+    return nil, fmt.Errorf("%w: %s", ErrTokenAlgorithm, token.Method.Alg())
+  }
+  id, ok := token.Header["kid"].(string)
+  if !ok {
+    return nil, ErrUnknownKey
+  }
+  key, err := kr.VerificationKey(id, time.Now())
+  if err != nil {
+    return nil, err
+  }
+  if token.Method.Alg() != key.Algorithm {
+    return nil, fmt.Errorf("%w: key %s is for %s, not %s", ErrTokenAlgorithm, key.ID, key.Algorithm, token.Method.Alg())
+  }
+  return key.Private.Public(), nil
+}
+
+// JWK is the public half of a signing key as published in a JWKS document
+// (RFC 7517).
+type JWK struct {
+  Kty string `json:"kty"`
+  Use string `json:"use"`
+  Alg string `json:"alg"`
+  Kid string `json:"kid"`
+  Crv string `json:"crv,omitempty"`
+  X   string `json:"x,omitempty"`
+  N   string `json:"n,omitempty"`
+  E   string `json:"e,omitempty"`
+}
+
+type JWKSet struct {
+  Keys []JWK `json:"keys"`
+}
+
+// JWKS lists every unexpired key, including ones that will only start
+// signing later, so verifiers can fetch them ahead of time.
+func (kr *Keyring) JWKS(now time.Time) JWKSet {
+  kr.mu.RLock()
+  defer kr.mu.RUnlock()
+
+  set := JWKSet{Keys: []JWK{}}
+  for _, key := range kr.keys {
+    if key.expired(now) {
+      continue
+    }
+    jwk := JWK{Use: "sig", Alg: key.Algorithm, Kid: key.ID}
+    switch public := key.Private.Public().(type) {
+    case ed25519.PublicKey:
+      jwk.Kty = "OKP"
+      jwk.Crv = "Ed25519"
+      jwk.X = base64.RawURLEncoding.EncodeToString(public)
+    case *rsa.PublicKey:
+      jwk.Kty = "RSA"
+      jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+      jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+    default:
+      continue
+    }
+    set.Keys = append(set.Keys, jwk)
+  }
+  return set
+}
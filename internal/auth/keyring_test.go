@@ -0,0 +1,124 @@
+package auth
+
+import (
+  "bytes"
+  "crypto/ed25519"
+  "crypto/rand"
+  "encoding/base64"
+  "errors"
+  "testing"
+  "time"
+
+  "github.com/google/uuid"
+)
+
+func TestKeyringAlgorithms(t *testing.T) {
+  for _, alg := range []string{AlgEdDSA, AlgRS256} {
+    key, err := GenerateSigningKey(alg)
+    if err != nil {
+      t.Fatalf("error generating %s key: %v", alg, err)
+    }
+
+    der, err := key.MarshalPrivateKey()
+    if err != nil {
+      t.Fatalf("error marshalling %s key: %v", alg, err)
+    }
+    parsed, err := ParseSigningKey(key.ID, alg, der)
+    if err != nil {
+      t.Fatalf("error parsing %s key: %v", alg, err)
+    }
+
+    userID := uuid.New()
+    token, err := MakeJWT(userID, RoleUser, NewKeyring(key), time.Minute)
+    if err != nil {
+      t.Fatalf("error signing with %s: %v", alg, err)
+    }
+    res, err := ValidateJWT(token, NewKeyring(parsed), 0)
+    if err != nil || res != userID {
+      t.Errorf("incorrect %s validation, expected %s, got %s (%v)", alg, userID, res, err)
+    }
+  }
+}
+
+func TestSealedSigningKeys(t *testing.T) {
+  kek := make([]byte, keyEncryptionKeySize)
+  rand.Read(kek)
+  key, _ := GenerateSigningKey(AlgEdDSA)
+
+  sealed, err := key.SealPrivateKey(kek)
+  if err != nil {
+    t.Fatalf("error sealing key: %v", err)
+  }
+  der, _ := key.MarshalPrivateKey()
+  if bytes.Contains(sealed, der) {
+    t.Errorf("expected the sealed key not to contain the private key")
+  }
+
+  opened, err := OpenSigningKey(key.ID, AlgEdDSA, sealed, kek)
+  if err != nil || !opened.Private.(ed25519.PrivateKey).Equal(key.Private) {
+    t.Errorf("expected the sealed key to open, got %v", err)
+  }
+
+  otherKEK := make([]byte, keyEncryptionKeySize)
+  rand.Read(otherKEK)
+  if _, err = OpenSigningKey(key.ID, AlgEdDSA, sealed, otherKEK); !errors.Is(err, ErrSealedKey) {
+    t.Errorf("expected ErrSealedKey with the wrong key encryption key, got %v", err)
+  }
+  if _, err = OpenSigningKey("another-id", AlgEdDSA, sealed, kek); !errors.Is(err, ErrSealedKey) {
+    t.Errorf("expected ErrSealedKey when opened under another ID, got %v", err)
+  }
+}
+
+func TestParseKeyEncryptionKey(t *testing.T) {
+  if _, err := ParseKeyEncryptionKey(base64.StdEncoding.EncodeToString(make([]byte, keyEncryptionKeySize))); err != nil {
+    t.Errorf("expected a 32 byte key to parse, got %v", err)
+  }
+  for _, s := range []string{"", "not base64!", base64.StdEncoding.EncodeToString(make([]byte, 16))} {
+    if _, err := ParseKeyEncryptionKey(s); err == nil {
+      t.Errorf("expected %q to be rejected", s)
+    }
+  }
+}
+
+func TestKeyringRotation(t *testing.T) {
+  now := time.Now()
+  old, _ := GenerateSigningKey(AlgEdDSA)
+  old.ActiveAt = now.Add(-time.Hour)
+  current, _ := GenerateSigningKey(AlgEdDSA)
+  current.ActiveAt = now.Add(-time.Minute)
+  next, _ := GenerateSigningKey(AlgRS256)
+  next.ActiveAt = now.Add(time.Hour)
+  keys := NewKeyring(next, old, current)
+
+  if key, err := keys.SigningKey(now); err != nil || key.ID != current.ID {
+    t.Errorf("incorrect signing key, expected %s, got %v (%v)", current.ID, key, err)
+  }
+
+  // tokens from the previous key stay valid until it expires
+  token, _ := MakeJWT(uuid.New(), RoleUser, NewKeyring(old), time.Minute)
+  if _, err := ValidateJWT(token, keys, 0); err != nil {
+    t.Errorf("expected token from old key to validate, got %v", err)
+  }
+  old.ExpiresAt = now.Add(-time.Second)
+  keys.Replace([]*SigningKey{old, current, next})
+  if _, err := ValidateJWT(token, keys, 0); err == nil {
+    t.Errorf("expected token from expired key to fail validation")
+  }
+
+  if set := keys.JWKS(now); len(set.Keys) != 2 || set.Keys[0].Kid != current.ID || set.Keys[1].Kty != "RSA" {
+    t.Errorf("incorrect JWKS, expected current and next keys, got %+v", set.Keys)
+  }
+}
+
+func TestKeyringUnknownKey(t *testing.T) {
+  signer, _ := GenerateSigningKey(AlgEdDSA)
+  other, _ := GenerateSigningKey(AlgEdDSA)
+  token, _ := MakeJWT(uuid.New(), RoleUser, NewKeyring(signer), time.Minute)
+  if _, err := ValidateJWT(token, NewKeyring(other), 0); err == nil {
+    t.Errorf("expected token signed with an unknown key to fail validation")
+  }
+
+  if _, err := NewKeyring().SigningKey(time.Now()); err != ErrNoSigningKey {
+    t.Errorf("expected ErrNoSigningKey from empty keyring, got %v", err)
+  }
+}
@@ -0,0 +1,26 @@
+package auth
+
+const (
+  RoleUser      = "user"
+  RoleModerator = "moderator"
+  RoleAdmin     = "admin"
+)
+
+// each role can do everything the roles below it can
+var roleRanks = map[string]int{
+  RoleUser:      1,
+  RoleModerator: 2,
+  RoleAdmin:     3,
+}
+
+func ValidRole(role string) bool {
+  _, ok := roleRanks[role]
+  return ok
+}
+
+// HasRole reports whether role grants at least the access of required.
+// Unknown roles grant nothing.
+func HasRole(role, required string) bool {
+  rank, ok := roleRanks[role]
+  return ok && rank >= roleRanks[required]
+}
@@ -0,0 +1,27 @@
+package auth
+
+import (
+  "testing"
+)
+
+func TestHasRole(t *testing.T) {
+  cases := []struct {
+    role     string
+    required string
+    expected bool
+  }{
+    {RoleUser, RoleUser, true},
+    {RoleUser, RoleModerator, false},
+    {RoleModerator, RoleModerator, true},
+    {RoleModerator, RoleAdmin, false},
+    {RoleAdmin, RoleModerator, true},
+    {RoleAdmin, RoleAdmin, true},
+    {"", RoleUser, false},
+    {"superuser", RoleUser, false},
+  }
+  for _, c := range cases {
+    if res := HasRole(c.role, c.required); res != c.expected {
+      t.Errorf("incorrect result for %q requiring %q, expected %v, got %v", c.role, c.required, c.expected, res)
+    }
+  }
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+  "slices"
+)
+
+const (
+  ScopeChirpsRead   = "chirps:read"
+  ScopeChirpsWrite  = "chirps:write"
+  ScopeSocialRead   = "social:read"
+  ScopeSocialWrite  = "social:write"
+  ScopeProfileWrite = "profile:write"
+
+  // ScopeAccount covers passwords, sessions, API keys and moderation. Only
+  // JWTs from a real login carry it; it can't be granted to an API key.
+  ScopeAccount = "account"
+)
+
+// APIKeyScopes are the scopes an API key can be granted.
+var APIKeyScopes = []string{
+  ScopeChirpsRead,
+  ScopeChirpsWrite,
+  ScopeSocialRead,
+  ScopeSocialWrite,
+  ScopeProfileWrite,
+}
+
+// AllScopes are held by a caller with a JWT.
+var AllScopes = append(slices.Clone(APIKeyScopes), ScopeAccount)
+
+func ValidScope(scope string) bool {
+  return slices.Contains(APIKeyScopes, scope)
+}
+
+// HasScope reports whether scopes grant required.
+func HasScope(scopes []string, required string) bool {
+  return slices.Contains(scopes, required)
+}
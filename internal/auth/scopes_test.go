@@ -0,0 +1,37 @@
+package auth
+
+import (
+  "testing"
+)
+
+func TestScopes(t *testing.T) {
+  for _, scope := range APIKeyScopes {
+    if !ValidScope(scope) {
+      t.Errorf("expected %q to be grantable to API keys", scope)
+    }
+  }
+  if ValidScope(ScopeAccount) {
+    t.Errorf("expected %q not to be grantable to API keys", ScopeAccount)
+  }
+  if ValidScope("chirps:admin") {
+    t.Errorf("expected unknown scope to be invalid")
+  }
+
+  cases := []struct {
+    scopes   []string
+    required string
+    expected bool
+  }{
+    {[]string{ScopeChirpsRead}, ScopeChirpsRead, true},
+    {[]string{ScopeChirpsRead}, ScopeChirpsWrite, false},
+    {[]string{ScopeChirpsWrite, ScopeSocialRead}, ScopeSocialRead, true},
+    {nil, ScopeChirpsRead, false},
+    {APIKeyScopes, ScopeAccount, false},
+    {AllScopes, ScopeAccount, true},
+  }
+  for _, c := range cases {
+    if res := HasScope(c.scopes, c.required); res != c.expected {
+      t.Errorf("incorrect result for %v requiring %q, expected %v, got %v", c.scopes, c.required, c.expected, res)
+    }
+  }
+}
@@ -3,6 +3,7 @@ package auth
 import (
   "errors"
   "encoding/hex"
+  "fmt"
   "net/http"
   "crypto/rand"
   "strings"
@@ -12,46 +13,119 @@ import (
   "github.com/google/uuid"
 )
 
+var ErrNoAuthHeader = errors.New("Authorization header missing")
+
+// GetBearerToken returns the JWT or API key from the Authorization header.
+// API keys can also be sent with the ApiKey scheme.
 func GetBearerToken(headers http.Header) (string, error) {
   authHeader := headers.Get("Authorization")
   if authHeader == "" {
-    return "", errors.New("Authorization header missing")
+    return "", ErrNoAuthHeader
   }
 
+  if key, ok := strings.CutPrefix(authHeader, "ApiKey "); ok {
+    return key, nil
+  }
   return strings.TrimPrefix(authHeader, "Bearer "), nil
 }
 
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
-  claims := jwt.RegisteredClaims{
-    Issuer: "chirpy",
-    IssuedAt: jwt.NewNumericDate(time.Now()),
-    ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
-    Subject: userID.String(),
+const (
+  TokenIssuer   = "chirpy"
+  TokenAudience = "chirpy-api"
+)
+
+// The ways a token can fail validation. ValidateJWT wraps one of these
+// around the underlying error so callers can tell them apart.
+var (
+  ErrTokenMalformed       = errors.New("token is malformed")
+  ErrTokenAlgorithm       = errors.New("token is signed with an algorithm that isn't allowed")
+  ErrTokenSignature       = errors.New("token signature is invalid")
+  ErrTokenExpired         = errors.New("token has expired")
+  ErrTokenNotYetValid     = errors.New("token is not valid yet")
+  ErrTokenIssuedInFuture  = errors.New("token was issued in the future")
+  ErrTokenIssuer          = errors.New("token has the wrong issuer")
+  ErrTokenAudience        = errors.New("token has the wrong audience")
+)
+
+// Claims are the registered claims plus the caller's role, which is
+// trusted until the token expires.
+type Claims struct {
+  jwt.RegisteredClaims
+  Role string `json:"role"`
+}
+
+// MakeJWT signs an access token for userID with the keyring's current key.
+func MakeJWT(userID uuid.UUID, role string, keys *Keyring, expiresIn time.Duration) (string, error) {
+  claims := Claims{
+    RegisteredClaims: jwt.RegisteredClaims{
+      Issuer: TokenIssuer,
+      Audience: jwt.ClaimStrings{TokenAudience},
+      IssuedAt: jwt.NewNumericDate(time.Now()),
+      ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+      Subject: userID.String(),
+    },
+    Role: role,
+  }
+
+  return keys.Sign(claims)
+}
+
+func ValidateJWT(tokenString string, keys *Keyring, leeway time.Duration) (uuid.UUID, error) {
+  userID, _, err := ValidateJWTRole(tokenString, keys, leeway)
+  return userID, err
+}
+
+// ValidateJWTRole is ValidateJWT for callers that also need the role claim.
+// Time based claims are given leeway either way to allow for clock skew.
+func ValidateJWTRole(tokenString string, keys *Keyring, leeway time.Duration) (uuid.UUID, string, error) {
+  token, err := jwt.ParseWithClaims(tokenString, &Claims{}, keys.keyfunc,
+    jwt.WithIssuer(TokenIssuer),
+    jwt.WithAudience(TokenAudience),
+    jwt.WithExpirationRequired(),
+    jwt.WithIssuedAt(),
+    jwt.WithLeeway(leeway),
+  )
+  if err != nil {
+    return uuid.Nil, "", tokenError(err)
   }
 
-  token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-  ss, err := token.SignedString([]byte(tokenSecret))
+  claims, ok := token.Claims.(*Claims)
+  if !ok || claims.IssuedAt == nil {
+    return uuid.Nil, "", ErrTokenMalformed
+  }
+  userID, err := uuid.Parse(claims.Subject)
   if err != nil {
-    return "", err
+    return uuid.Nil, "", fmt.Errorf("%w: %s", ErrTokenMalformed, err)
   }
-  return ss, nil
+  return userID, claims.Role, nil
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-  token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(token *jwt.Token) (any, error) {
-	  return []byte(tokenSecret), nil
-  })
-  if err != nil {
-    return uuid.Nil, err
-  } else if claims, ok := token.Claims.(*jwt.RegisteredClaims); ok {
-    userID, err := uuid.Parse(claims.Subject)
-    if err != nil {
-      return uuid.Nil, err
-    }
-    return userID, nil
-  } else {
-    return uuid.Nil, errors.New("invalid claim")
+// tokenError translates a parser error into one of the Err* values above.
+func tokenError(err error) error {
+  var kind error
+  switch {
+  case errors.Is(err, ErrUnknownKey):
+    return err
+  case errors.Is(err, ErrTokenAlgorithm):
+    kind = ErrTokenAlgorithm
+  case errors.Is(err, jwt.ErrTokenMalformed), errors.Is(err, jwt.ErrTokenRequiredClaimMissing):
+    kind = ErrTokenMalformed
+  case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+    kind = ErrTokenSignature
+  case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+    kind = ErrTokenIssuer
+  case errors.Is(err, jwt.ErrTokenInvalidAudience):
+    kind = ErrTokenAudience
+  case errors.Is(err, jwt.ErrTokenExpired):
+    kind = ErrTokenExpired
+  case errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+    kind = ErrTokenIssuedInFuture
+  case errors.Is(err, jwt.ErrTokenNotValidYet):
+    kind = ErrTokenNotYetValid
+  default:
+    kind = ErrTokenMalformed
   }
+  return fmt.Errorf("%w: %s", kind, err)
 }
 
 func MakeRefreshToken() (string, error) {
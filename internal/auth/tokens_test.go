@@ -1,9 +1,11 @@
 package auth
 
 import (
+  "errors"
   "testing"
   "time"
     
+  "github.com/golang-jwt/jwt/v5"
   "github.com/google/uuid"
 )
 
@@ -12,13 +14,17 @@ func TestJWT(t *testing.T) {
   if err != nil {
     t.Errorf("error generating userID: %v", err)
   }
-  password := "test"
-  signedString, err := MakeJWT(userID, password, time.Second)
+  key, err := GenerateSigningKey(AlgEdDSA)
+  if err != nil {
+    t.Fatalf("error generating key: %v", err)
+  }
+  keys := NewKeyring(key)
+  signedString, err := MakeJWT(userID, RoleModerator, keys, time.Second)
   if err != nil {
     t.Errorf("error generating jwt: %v", err)
   }
   
-  res, err := ValidateJWT(signedString, password)
+  res, err := ValidateJWT(signedString, keys, 0)
   if err != nil {
     t.Errorf("error validating jwt: %v", err)
   }
@@ -27,10 +33,77 @@ func TestJWT(t *testing.T) {
     t.Errorf("incorrect uuid from claim, expected %s, got %s", userID.String(), res.String())
   }
 
+  _, role, err := ValidateJWTRole(signedString, keys, 0)
+  if err != nil || role != RoleModerator {
+    t.Errorf("incorrect role from claim, expected %s, got %q (%v)", RoleModerator, role, err)
+  }
+
   time.Sleep(time.Second)
 
-  res, err = ValidateJWT(signedString, password)
+  res, err = ValidateJWT(signedString, keys, 0)
   if err == nil {
     t.Errorf("incorrect JWT validation, expected expired, but passed validation")
   }
 }
+
+func TestJWTValidationErrors(t *testing.T) {
+  key, err := GenerateSigningKey(AlgEdDSA)
+  if err != nil {
+    t.Fatalf("error generating key: %v", err)
+  }
+  keys := NewKeyring(key)
+  now := time.Now()
+
+  claims := func(change func(*Claims)) *Claims {
+    c := &Claims{
+      RegisteredClaims: jwt.RegisteredClaims{
+        Issuer: TokenIssuer,
+        Audience: jwt.ClaimStrings{TokenAudience},
+        IssuedAt: jwt.NewNumericDate(now),
+        ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+        Subject: uuid.NewString(),
+      },
+      Role: RoleUser,
+    }
+    change(c)
+    return c
+  }
+
+  hmac, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims(func(*Claims) {})).SignedString([]byte("secret"))
+  none, _ := jwt.NewWithClaims(jwt.SigningMethodNone, claims(func(*Claims) {})).SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+  cases := map[string]struct {
+    token   string
+    leeway  time.Duration
+    err     error
+  }{
+    "garbage":          {"not.a.token", 0, ErrTokenMalformed},
+    "hmac":             {hmac, 0, ErrTokenAlgorithm},
+    "none":             {none, 0, ErrTokenAlgorithm},
+    "wrong issuer":     {sign(t, keys, claims(func(c *Claims) { c.Issuer = "other" })), 0, ErrTokenIssuer},
+    "wrong audience":   {sign(t, keys, claims(func(c *Claims) { c.Audience = jwt.ClaimStrings{"other"} })), 0, ErrTokenAudience},
+    "no expiry":        {sign(t, keys, claims(func(c *Claims) { c.ExpiresAt = nil })), 0, ErrTokenMalformed},
+    "no issued at":     {sign(t, keys, claims(func(c *Claims) { c.IssuedAt = nil })), 0, ErrTokenMalformed},
+    "expired":          {sign(t, keys, claims(func(c *Claims) { c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute)) })), 0, ErrTokenExpired},
+    "expired in skew":  {sign(t, keys, claims(func(c *Claims) { c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute)) })), 2 * time.Minute, nil},
+    "issued in future": {sign(t, keys, claims(func(c *Claims) { c.IssuedAt = jwt.NewNumericDate(now.Add(time.Minute)) })), 0, ErrTokenIssuedInFuture},
+    "future in skew":   {sign(t, keys, claims(func(c *Claims) { c.IssuedAt = jwt.NewNumericDate(now.Add(time.Minute)) })), 2 * time.Minute, nil},
+    "not before":       {sign(t, keys, claims(func(c *Claims) { c.NotBefore = jwt.NewNumericDate(now.Add(time.Minute)) })), 0, ErrTokenNotYetValid},
+  }
+  for name, c := range cases {
+    _, err := ValidateJWT(c.token, keys, c.leeway)
+    if c.err == nil && err != nil {
+      t.Errorf("%s: expected token to validate, got %v", name, err)
+    } else if c.err != nil && !errors.Is(err, c.err) {
+      t.Errorf("%s: incorrect error, expected %v, got %v", name, c.err, err)
+    }
+  }
+}
+
+func sign(t *testing.T, keys *Keyring, claims *Claims) string {
+  token, err := keys.Sign(claims)
+  if err != nil {
+    t.Fatalf("error signing token: %v", err)
+  }
+  return token
+}
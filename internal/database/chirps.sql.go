@@ -7,29 +7,68 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
-VALUES (
-    gen_random_uuid(),
-    now(),
-    now(),
-    $1,
-    $2
-)
-RETURNING id, created_at, updated_at, body, user_id
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, conversation_id, moderation_state)
+SELECT new_chirp.id, now(), now(), $1::text, $2::uuid, new_chirp.id, $3::text
+FROM (SELECT gen_random_uuid() AS id) AS new_chirp
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, conversation_id, deleted_at, repost_of, kind, search_vector, moderation_state
 `
 
 type CreateChirpParams struct {
-	Body   string    `json:"body"`
-	UserID uuid.UUID `json:"user_id"`
+	Body            string    `json:"body"`
+	UserID          uuid.UUID `json:"user_id"`
+	ModerationState string    `json:"moderation_state"`
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID, arg.ModerationState)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.ConversationID,
+		&i.DeletedAt,
+		&i.RepostOf,
+		&i.Kind,
+		&i.SearchVector,
+		&i.ModerationState,
+	)
+	return i, err
+}
+
+const createReply = `-- name: CreateReply :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to, conversation_id, moderation_state)
+SELECT gen_random_uuid(), now(), now(), $1::text, $2::uuid, parent.id, parent.conversation_id, $3::text
+FROM chirps AS parent
+WHERE parent.id = $4 AND parent.deleted_at IS NULL
+  AND (parent.moderation_state = 'visible' OR parent.user_id = $2)
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, conversation_id, deleted_at, repost_of, kind, search_vector, moderation_state
+`
+
+type CreateReplyParams struct {
+	Body            string    `json:"body"`
+	UserID          uuid.UUID `json:"user_id"`
+	ModerationState string    `json:"moderation_state"`
+	InReplyTo       uuid.UUID `json:"in_reply_to"`
+}
+
+func (q *Queries) CreateReply(ctx context.Context, arg CreateReplyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, createReply,
+		arg.Body,
+		arg.UserID,
+		arg.ModerationState,
+		arg.InReplyTo,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,6 +76,55 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.ConversationID,
+		&i.DeletedAt,
+		&i.RepostOf,
+		&i.Kind,
+		&i.SearchVector,
+		&i.ModerationState,
+	)
+	return i, err
+}
+
+const createRepost = `-- name: CreateRepost :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, conversation_id, repost_of, kind, moderation_state)
+SELECT new_chirp.id, now(), now(), $1::text, $2::uuid, new_chirp.id, original.id, $3::text, $4::text
+FROM (SELECT gen_random_uuid() AS id) AS new_chirp, chirps AS original
+WHERE original.id = $5 AND original.deleted_at IS NULL
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, conversation_id, deleted_at, repost_of, kind, search_vector, moderation_state
+`
+
+type CreateRepostParams struct {
+	Body            string    `json:"body"`
+	UserID          uuid.UUID `json:"user_id"`
+	Kind            string    `json:"kind"`
+	ModerationState string    `json:"moderation_state"`
+	RepostOf        uuid.UUID `json:"repost_of"`
+}
+
+func (q *Queries) CreateRepost(ctx context.Context, arg CreateRepostParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, createRepost,
+		arg.Body,
+		arg.UserID,
+		arg.Kind,
+		arg.ModerationState,
+		arg.RepostOf,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.ConversationID,
+		&i.DeletedAt,
+		&i.RepostOf,
+		&i.Kind,
+		&i.SearchVector,
+		&i.ModerationState,
 	)
 	return i, err
 }
@@ -50,8 +138,21 @@ func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const deleteRechirps = `-- name: DeleteRechirps :exec
+DELETE FROM chirps WHERE repost_of = $1 AND kind = 'rechirp'
+`
+
+func (q *Queries) DeleteRechirps(ctx context.Context, repostOf uuid.NullUUID) error {
+	_, err := q.db.ExecContext(ctx, deleteRechirps, repostOf)
+	return err
+}
+
 const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps ORDER BY created_at
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, conversation_id, deleted_at, repost_of, kind, search_vector, moderation_state FROM chirps
+WHERE deleted_at IS NULL
+  AND moderation_state = 'visible'
+  AND user_id NOT IN (SELECT id FROM users WHERE suspension_hides_chirps AND suspended_at IS NOT NULL AND (suspended_until IS NULL OR suspended_until > now()))
+ORDER BY created_at
 `
 
 func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
@@ -69,6 +170,13 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.InReplyTo,
+			&i.ConversationID,
+			&i.DeletedAt,
+			&i.RepostOf,
+			&i.Kind,
+			&i.SearchVector,
+			&i.ModerationState,
 		); err != nil {
 			return nil, err
 		}
@@ -84,7 +192,7 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const getChirp = `-- name: GetChirp :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE id = $1
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, conversation_id, deleted_at, repost_of, kind, search_vector, moderation_state FROM chirps WHERE id = $1
 `
 
 func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -96,10 +204,259 @@ func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.ConversationID,
+		&i.DeletedAt,
+		&i.RepostOf,
+		&i.Kind,
+		&i.SearchVector,
+		&i.ModerationState,
 	)
 	return i, err
 }
 
+const getChirpForUpdate = `-- name: GetChirpForUpdate :one
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, conversation_id, deleted_at, repost_of, kind, search_vector, moderation_state FROM chirps WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetChirpForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpForUpdate, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.ConversationID,
+		&i.DeletedAt,
+		&i.RepostOf,
+		&i.Kind,
+		&i.SearchVector,
+		&i.ModerationState,
+	)
+	return i, err
+}
+
+const getChirpsAfter = `-- name: GetChirpsAfter :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, conversation_id, deleted_at, repost_of, kind, search_vector, moderation_state FROM chirps
+WHERE deleted_at IS NULL
+  AND moderation_state = 'visible'
+  AND user_id NOT IN (SELECT id FROM users WHERE suspension_hides_chirps AND suspended_at IS NOT NULL AND (suspended_until IS NULL OR suspended_until > now()))
+  AND ($1::uuid IS NULL OR user_id = $1)
+  AND ($2::uuid IS NULL OR user_id NOT IN (SELECT blocked_id FROM blocks WHERE blocker_id = $2 UNION SELECT blocker_id FROM blocks WHERE blocked_id = $2))
+  AND (user_id NOT IN (SELECT id FROM users WHERE protected) OR user_id = $2 OR user_id IN (SELECT followee_id FROM follows WHERE follower_id = $2))
+  AND ($3::timestamp IS NULL OR (created_at, id) > ($3, $4::uuid))
+ORDER BY created_at, id
+LIMIT $5
+`
+
+type GetChirpsAfterParams struct {
+	AuthorID  uuid.NullUUID `json:"author_id"`
+	ViewerID  uuid.NullUUID `json:"viewer_id"`
+	CreatedAt sql.NullTime  `json:"created_at"`
+	ID        uuid.NullUUID `json:"id"`
+	RowLimit  int32         `json:"row_limit"`
+}
+
+func (q *Queries) GetChirpsAfter(ctx context.Context, arg GetChirpsAfterParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsAfter,
+		arg.AuthorID,
+		arg.ViewerID,
+		arg.CreatedAt,
+		arg.ID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.ConversationID,
+			&i.DeletedAt,
+			&i.RepostOf,
+			&i.Kind,
+			&i.SearchVector,
+			&i.ModerationState,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsBefore = `-- name: GetChirpsBefore :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, conversation_id, deleted_at, repost_of, kind, search_vector, moderation_state FROM chirps
+WHERE deleted_at IS NULL
+  AND moderation_state = 'visible'
+  AND user_id NOT IN (SELECT id FROM users WHERE suspension_hides_chirps AND suspended_at IS NOT NULL AND (suspended_until IS NULL OR suspended_until > now()))
+  AND ($1::uuid IS NULL OR user_id = $1)
+  AND ($2::uuid IS NULL OR user_id NOT IN (SELECT blocked_id FROM blocks WHERE blocker_id = $2 UNION SELECT blocker_id FROM blocks WHERE blocked_id = $2))
+  AND (user_id NOT IN (SELECT id FROM users WHERE protected) OR user_id = $2 OR user_id IN (SELECT followee_id FROM follows WHERE follower_id = $2))
+  AND ($3::timestamp IS NULL OR (created_at, id) < ($3, $4::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $5
+`
+
+type GetChirpsBeforeParams struct {
+	AuthorID  uuid.NullUUID `json:"author_id"`
+	ViewerID  uuid.NullUUID `json:"viewer_id"`
+	CreatedAt sql.NullTime  `json:"created_at"`
+	ID        uuid.NullUUID `json:"id"`
+	RowLimit  int32         `json:"row_limit"`
+}
+
+func (q *Queries) GetChirpsBefore(ctx context.Context, arg GetChirpsBeforeParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsBefore,
+		arg.AuthorID,
+		arg.ViewerID,
+		arg.CreatedAt,
+		arg.ID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.ConversationID,
+			&i.DeletedAt,
+			&i.RepostOf,
+			&i.Kind,
+			&i.SearchVector,
+			&i.ModerationState,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, conversation_id, deleted_at, repost_of, kind, search_vector, moderation_state FROM chirps WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) GetChirpsByIDs(ctx context.Context, ids []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.ConversationID,
+			&i.DeletedAt,
+			&i.RepostOf,
+			&i.Kind,
+			&i.SearchVector,
+			&i.ModerationState,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getConversation = `-- name: GetConversation :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, conversation_id, deleted_at, repost_of, kind, search_vector, moderation_state FROM chirps WHERE conversation_id = $1 ORDER BY created_at, id
+`
+
+func (q *Queries) GetConversation(ctx context.Context, conversationID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getConversation, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.ConversationID,
+			&i.DeletedAt,
+			&i.RepostOf,
+			&i.Kind,
+			&i.SearchVector,
+			&i.ModerationState,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const hasReplies = `-- name: HasReplies :one
+SELECT EXISTS (SELECT 1 FROM chirps WHERE in_reply_to = $1)
+`
+
+func (q *Queries) HasReplies(ctx context.Context, inReplyTo uuid.NullUUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, hasReplies, inReplyTo)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const resetChirps = `-- name: ResetChirps :exec
 DELETE FROM chirps
 `
@@ -108,3 +465,125 @@ func (q *Queries) ResetChirps(ctx context.Context) error {
 	_, err := q.db.ExecContext(ctx, resetChirps)
 	return err
 }
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, conversation_id, deleted_at, repost_of, kind, search_vector, moderation_state FROM chirps
+WHERE deleted_at IS NULL
+  AND moderation_state = 'visible'
+  AND user_id NOT IN (SELECT id FROM users WHERE suspension_hides_chirps AND suspended_at IS NOT NULL AND (suspended_until IS NULL OR suspended_until > now()))
+  AND ($1::text = '' OR search_vector @@ websearch_to_tsquery('english', $1))
+  AND ($2::uuid IS NULL OR user_id = $2)
+  AND ($3::uuid IS NULL OR user_id NOT IN (SELECT blocked_id FROM blocks WHERE blocker_id = $3 UNION SELECT blocker_id FROM blocks WHERE blocked_id = $3))
+  AND (user_id NOT IN (SELECT id FROM users WHERE protected) OR user_id = $3 OR user_id IN (SELECT followee_id FROM follows WHERE follower_id = $3))
+  AND ($4::timestamp IS NULL OR created_at >= $4)
+  AND ($5::timestamp IS NULL OR created_at < $5)
+ORDER BY ts_rank(search_vector, websearch_to_tsquery('english', $1)) DESC, created_at DESC, id DESC
+LIMIT $6 OFFSET $7
+`
+
+type SearchChirpsParams struct {
+	Query     string        `json:"query"`
+	AuthorID  uuid.NullUUID `json:"author_id"`
+	ViewerID  uuid.NullUUID `json:"viewer_id"`
+	Since     sql.NullTime  `json:"since"`
+	Until     sql.NullTime  `json:"until"`
+	RowLimit  int32         `json:"row_limit"`
+	RowOffset int32         `json:"row_offset"`
+}
+
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps,
+		arg.Query,
+		arg.AuthorID,
+		arg.ViewerID,
+		arg.Since,
+		arg.Until,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.ConversationID,
+			&i.DeletedAt,
+			&i.RepostOf,
+			&i.Kind,
+			&i.SearchVector,
+			&i.ModerationState,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setChirpModerationState = `-- name: SetChirpModerationState :exec
+UPDATE chirps SET moderation_state = $2 WHERE id = $1
+`
+
+type SetChirpModerationStateParams struct {
+	ID              uuid.UUID `json:"id"`
+	ModerationState string    `json:"moderation_state"`
+}
+
+func (q *Queries) SetChirpModerationState(ctx context.Context, arg SetChirpModerationStateParams) error {
+	_, err := q.db.ExecContext(ctx, setChirpModerationState, arg.ID, arg.ModerationState)
+	return err
+}
+
+const tombstoneChirp = `-- name: TombstoneChirp :exec
+UPDATE chirps SET body = '', updated_at = now(), deleted_at = now() WHERE id = $1
+`
+
+func (q *Queries) TombstoneChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, tombstoneChirp, id)
+	return err
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps SET body = $2, updated_at = now() WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, conversation_id, deleted_at, repost_of, kind, search_vector, moderation_state
+`
+
+type UpdateChirpBodyParams struct {
+	ID   uuid.UUID `json:"id"`
+	Body string    `json:"body"`
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.ID, arg.Body)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.ConversationID,
+		&i.DeletedAt,
+		&i.RepostOf,
+		&i.Kind,
+		&i.SearchVector,
+		&i.ModerationState,
+	)
+	return i, err
+}
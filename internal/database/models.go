@@ -11,27 +11,208 @@ import (
 	"github.com/google/uuid"
 )
 
+type APIKey struct {
+	ID         uuid.UUID    `json:"id"`
+	UserID     uuid.UUID    `json:"user_id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	HashedKey  string       `json:"hashed_key"`
+	Scopes     []string     `json:"scopes"`
+	CreatedAt  time.Time    `json:"created_at"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+}
+
+type Block struct {
+	BlockerID uuid.UUID `json:"blocker_id"`
+	BlockedID uuid.UUID `json:"blocked_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Chirp struct {
+	ID              uuid.UUID     `json:"id"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+	Body            string        `json:"body"`
+	UserID          uuid.UUID     `json:"user_id"`
+	InReplyTo       uuid.NullUUID `json:"in_reply_to"`
+	ConversationID  uuid.UUID     `json:"conversation_id"`
+	DeletedAt       sql.NullTime  `json:"deleted_at"`
+	RepostOf        uuid.NullUUID `json:"repost_of"`
+	Kind            string        `json:"kind"`
+	SearchVector    interface{}   `json:"search_vector"`
+	ModerationState string        `json:"moderation_state"`
+}
+
+type ChirpMention struct {
+	ChirpID     uuid.UUID `json:"chirp_id"`
+	UserID      uuid.UUID `json:"user_id"`
+	StartOffset int32     `json:"start_offset"`
+	EndOffset   int32     `json:"end_offset"`
+}
+
+type ChirpRevision struct {
+	ID         uuid.UUID `json:"id"`
+	ChirpID    uuid.UUID `json:"chirp_id"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+type ChirpTag struct {
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	Tag       string    `json:"tag"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Follow struct {
+	FollowerID uuid.UUID `json:"follower_id"`
+	FolloweeID uuid.UUID `json:"followee_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type FollowRequest struct {
+	RequesterID uuid.UUID `json:"requester_id"`
+	TargetID    uuid.UUID `json:"target_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type Like struct {
+	UserID    uuid.UUID `json:"user_id"`
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MediaVariant struct {
+	MediaID     uuid.UUID `json:"media_id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	SizeBytes   int64     `json:"size_bytes"`
+	StorageKey  string    `json:"storage_key"`
+	Width       int32     `json:"width"`
+	Height      int32     `json:"height"`
+}
+
+type Medium struct {
+	ID          uuid.UUID      `json:"id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UserID      uuid.UUID      `json:"user_id"`
+	ContentType string         `json:"content_type"`
+	SizeBytes   int64          `json:"size_bytes"`
+	StorageKey  string         `json:"storage_key"`
+	ChirpID     uuid.NullUUID  `json:"chirp_id"`
+	Position    int32          `json:"position"`
+	Width       sql.NullInt32  `json:"width"`
+	Height      sql.NullInt32  `json:"height"`
+	Blurhash    sql.NullString `json:"blurhash"`
+}
+
+type ModerationLog struct {
+	ID            uuid.UUID     `json:"id"`
+	CreatedAt     time.Time     `json:"created_at"`
+	ModeratorID   uuid.NullUUID `json:"moderator_id"`
+	ReportID      uuid.NullUUID `json:"report_id"`
+	Action        string        `json:"action"`
+	TargetUserID  uuid.NullUUID `json:"target_user_id"`
+	TargetChirpID uuid.NullUUID `json:"target_chirp_id"`
+	Note          string        `json:"note"`
+}
+
+type ModerationRule struct {
 	ID        uuid.UUID `json:"id"`
 	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
+	Kind      string    `json:"kind"`
+	Pattern   string    `json:"pattern"`
+	Action    string    `json:"action"`
+}
+
+type Mute struct {
+	MuterID   uuid.UUID `json:"muter_id"`
+	MutedID   uuid.UUID `json:"muted_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MutedKeyword struct {
 	UserID    uuid.UUID `json:"user_id"`
+	Keyword   string    `json:"keyword"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type RefreshToken struct {
-	Token     string       `json:"token"`
-	CreatedAt time.Time    `json:"created_at"`
-	UpdatedAt time.Time    `json:"updated_at"`
-	ExpiresAt time.Time    `json:"expires_at"`
-	RevokedAt sql.NullTime `json:"revoked_at"`
-	UserID    uuid.UUID    `json:"user_id"`
+	Token       string         `json:"token"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	ExpiresAt   time.Time      `json:"expires_at"`
+	RevokedAt   sql.NullTime   `json:"revoked_at"`
+	UserID      uuid.UUID      `json:"user_id"`
+	FamilyID    uuid.UUID      `json:"family_id"`
+	ReplacedBy  sql.NullString `json:"replaced_by"`
+	UserAgent   string         `json:"user_agent"`
+	IpAddress   string         `json:"ip_address"`
+	LastUsedAt  time.Time      `json:"last_used_at"`
+	SessionName string         `json:"session_name"`
+}
+
+type Report struct {
+	ID         uuid.UUID      `json:"id"`
+	CreatedAt  time.Time      `json:"created_at"`
+	ChirpID    uuid.NullUUID  `json:"chirp_id"`
+	AuthorID   uuid.UUID      `json:"author_id"`
+	ChirpBody  string         `json:"chirp_body"`
+	ReporterID uuid.NullUUID  `json:"reporter_id"`
+	Reason     string         `json:"reason"`
+	Details    string         `json:"details"`
+	Status     string         `json:"status"`
+	ClaimedBy  uuid.NullUUID  `json:"claimed_by"`
+	ClaimedAt  sql.NullTime   `json:"claimed_at"`
+	ResolvedBy uuid.NullUUID  `json:"resolved_by"`
+	ResolvedAt sql.NullTime   `json:"resolved_at"`
+	Resolution sql.NullString `json:"resolution"`
+}
+
+type SecurityEvent struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    uuid.UUID `json:"user_id"`
+	Kind      string    `json:"kind"`
+	IpAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Details   string    `json:"details"`
+}
+
+type SigningKey struct {
+	Kid                 string       `json:"kid"`
+	Algorithm           string       `json:"algorithm"`
+	EncryptedPrivateKey []byte       `json:"encrypted_private_key"`
+	CreatedAt           time.Time    `json:"created_at"`
+	ActiveAt            time.Time    `json:"active_at"`
+	ExpiresAt           sql.NullTime `json:"expires_at"`
 }
 
 type User struct {
-	ID             uuid.UUID `json:"id"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
-	Email          string    `json:"email"`
-	HashedPassword string    `json:"hashed_password"`
+	ID                    uuid.UUID    `json:"id"`
+	CreatedAt             time.Time    `json:"created_at"`
+	UpdatedAt             time.Time    `json:"updated_at"`
+	Email                 string       `json:"email"`
+	HashedPassword        string       `json:"hashed_password"`
+	Username              string       `json:"username"`
+	DisplayName           string       `json:"display_name"`
+	Bio                   string       `json:"bio"`
+	AvatarUrl             string       `json:"avatar_url"`
+	Role                  string       `json:"role"`
+	SuspendedAt           sql.NullTime `json:"suspended_at"`
+	SuspendedUntil        sql.NullTime `json:"suspended_until"`
+	SuspensionReason      string       `json:"suspension_reason"`
+	SuspensionHidesChirps bool         `json:"suspension_hides_chirps"`
+	Protected             bool         `json:"protected"`
+}
+
+type UserWarning struct {
+	ID          uuid.UUID     `json:"id"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UserID      uuid.UUID     `json:"user_id"`
+	ModeratorID uuid.NullUUID `json:"moderator_id"`
+	ReportID    uuid.NullUUID `json:"report_id"`
+	Note        string        `json:"note"`
 }
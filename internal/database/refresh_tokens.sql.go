@@ -7,31 +7,49 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 const createRefreshToken = `-- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, expires_at, user_id)
+INSERT INTO refresh_tokens (token, created_at, updated_at, expires_at, user_id, family_id, user_agent, ip_address, last_used_at, session_name)
 VALUES (
     $1,
     now(),
     now(),
     $2,
-    $3
+    $3,
+    $4,
+    $5,
+    $6,
+    now(),
+    $7
 )
-RETURNING token, created_at, updated_at, expires_at, revoked_at, user_id
+RETURNING token, created_at, updated_at, expires_at, revoked_at, user_id, family_id, replaced_by, user_agent, ip_address, last_used_at, session_name
 `
 
 type CreateRefreshTokenParams struct {
-	Token     string    `json:"token"`
-	ExpiresAt time.Time `json:"expires_at"`
-	UserID    uuid.UUID `json:"user_id"`
+	Token       string    `json:"token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	UserID      uuid.UUID `json:"user_id"`
+	FamilyID    uuid.UUID `json:"family_id"`
+	UserAgent   string    `json:"user_agent"`
+	IpAddress   string    `json:"ip_address"`
+	SessionName string    `json:"session_name"`
 }
 
 func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, createRefreshToken, arg.Token, arg.ExpiresAt, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createRefreshToken,
+		arg.Token,
+		arg.ExpiresAt,
+		arg.UserID,
+		arg.FamilyID,
+		arg.UserAgent,
+		arg.IpAddress,
+		arg.SessionName,
+	)
 	var i RefreshToken
 	err := row.Scan(
 		&i.Token,
@@ -40,12 +58,18 @@ func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshToken
 		&i.ExpiresAt,
 		&i.RevokedAt,
 		&i.UserID,
+		&i.FamilyID,
+		&i.ReplacedBy,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
+		&i.SessionName,
 	)
 	return i, err
 }
 
 const getRefreshToken = `-- name: GetRefreshToken :one
-SELECT token, created_at, updated_at, expires_at, revoked_at, user_id FROM refresh_tokens WHERE token = $1 AND revoked_at IS NULL
+SELECT token, created_at, updated_at, expires_at, revoked_at, user_id, family_id, replaced_by, user_agent, ip_address, last_used_at, session_name FROM refresh_tokens WHERE token = $1
 `
 
 func (q *Queries) GetRefreshToken(ctx context.Context, token string) (RefreshToken, error) {
@@ -58,26 +82,83 @@ func (q *Queries) GetRefreshToken(ctx context.Context, token string) (RefreshTok
 		&i.ExpiresAt,
 		&i.RevokedAt,
 		&i.UserID,
+		&i.FamilyID,
+		&i.ReplacedBy,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
+		&i.SessionName,
 	)
 	return i, err
 }
 
-const getRefreshTokenFromUserID = `-- name: GetRefreshTokenFromUserID :one
-SELECT token, created_at, updated_at, expires_at, revoked_at, user_id FROM refresh_tokens WHERE user_id = $1 AND revoked_at IS NULL
+const getSessions = `-- name: GetSessions :many
+SELECT family_id,
+    (SELECT min(created_at) FROM refresh_tokens AS family WHERE family.family_id = refresh_tokens.family_id)::timestamp AS started_at,
+    last_used_at, user_agent, ip_address, session_name, expires_at
+FROM refresh_tokens
+WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > now()
+ORDER BY last_used_at DESC
 `
 
-func (q *Queries) GetRefreshTokenFromUserID(ctx context.Context, userID uuid.UUID) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, getRefreshTokenFromUserID, userID)
-	var i RefreshToken
-	err := row.Scan(
-		&i.Token,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.ExpiresAt,
-		&i.RevokedAt,
-		&i.UserID,
-	)
-	return i, err
+type GetSessionsRow struct {
+	FamilyID    uuid.UUID `json:"family_id"`
+	StartedAt   time.Time `json:"started_at"`
+	LastUsedAt  time.Time `json:"last_used_at"`
+	UserAgent   string    `json:"user_agent"`
+	IpAddress   string    `json:"ip_address"`
+	SessionName string    `json:"session_name"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (q *Queries) GetSessions(ctx context.Context, userID uuid.UUID) ([]GetSessionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSessions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSessionsRow
+	for rows.Next() {
+		var i GetSessionsRow
+		if err := rows.Scan(
+			&i.FamilyID,
+			&i.StartedAt,
+			&i.LastUsedAt,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.SessionName,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const renameSession = `-- name: RenameSession :execrows
+UPDATE refresh_tokens SET session_name = $3, updated_at = now()
+WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL
+`
+
+type RenameSessionParams struct {
+	FamilyID    uuid.UUID `json:"family_id"`
+	UserID      uuid.UUID `json:"user_id"`
+	SessionName string    `json:"session_name"`
+}
+
+func (q *Queries) RenameSession(ctx context.Context, arg RenameSessionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, renameSession, arg.FamilyID, arg.UserID, arg.SessionName)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const resetRefreshTokens = `-- name: ResetRefreshTokens :exec
@@ -97,3 +178,57 @@ func (q *Queries) RevokeRefreshToken(ctx context.Context, token string) error {
 	_, err := q.db.ExecContext(ctx, revokeRefreshToken, token)
 	return err
 }
+
+const revokeRefreshTokenFamily = `-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens SET revoked_at = now(), updated_at = now() WHERE family_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeRefreshTokenFamily, familyID)
+	return err
+}
+
+const revokeSession = `-- name: RevokeSession :execrows
+UPDATE refresh_tokens SET revoked_at = now(), updated_at = now()
+WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL
+`
+
+type RevokeSessionParams struct {
+	FamilyID uuid.UUID `json:"family_id"`
+	UserID   uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) RevokeSession(ctx context.Context, arg RevokeSessionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeSession, arg.FamilyID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeUserRefreshTokens = `-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens SET revoked_at = now(), updated_at = now() WHERE user_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeUserRefreshTokens, userID)
+	return err
+}
+
+const rotateRefreshToken = `-- name: RotateRefreshToken :execrows
+UPDATE refresh_tokens SET revoked_at = now(), updated_at = now(), replaced_by = $2
+WHERE token = $1 AND revoked_at IS NULL
+`
+
+type RotateRefreshTokenParams struct {
+	Token      string         `json:"token"`
+	ReplacedBy sql.NullString `json:"replaced_by"`
+}
+
+func (q *Queries) RotateRefreshToken(ctx context.Context, arg RotateRefreshTokenParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, rotateRefreshToken, arg.Token, arg.ReplacedBy)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -7,8 +7,11 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const changeEmailPassword = `-- name: ChangeEmailPassword :exec
@@ -26,25 +29,38 @@ func (q *Queries) ChangeEmailPassword(ctx context.Context, arg ChangeEmailPasswo
 	return err
 }
 
+const countUsersWithRole = `-- name: CountUsersWithRole :one
+SELECT count(*) FROM users WHERE role = $1
+`
+
+func (q *Queries) CountUsersWithRole(ctx context.Context, role string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUsersWithRole, role)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, username)
 VALUES (
     gen_random_uuid(),
     now(),
     now(),
     $1,
-    $2
+    $2,
+    $3
 )
-RETURNING id, created_at, updated_at, email, hashed_password
+RETURNING id, created_at, updated_at, email, hashed_password, username, display_name, bio, avatar_url, role, suspended_at, suspended_until, suspension_reason, suspension_hides_chirps, protected
 `
 
 type CreateUserParams struct {
 	Email          string `json:"email"`
 	HashedPassword string `json:"hashed_password"`
+	Username       string `json:"username"`
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword, arg.Username)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -52,12 +68,62 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.UpdatedAt,
 		&i.Email,
 		&i.HashedPassword,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Role,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.SuspensionHidesChirps,
+		&i.Protected,
+	)
+	return i, err
+}
+
+const getPublicProfile = `-- name: GetPublicProfile :one
+SELECT id, created_at, username, display_name, bio, avatar_url, protected,
+    (SELECT count(*) FROM follows WHERE followee_id = users.id) AS follower_count,
+    (SELECT count(*) FROM follows WHERE follower_id = users.id) AS following_count,
+    (SELECT count(*) FROM chirps WHERE user_id = users.id AND deleted_at IS NULL AND moderation_state = 'visible') AS chirp_count
+FROM users
+WHERE lower(username) = lower($1)
+`
+
+type GetPublicProfileRow struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Username       string    `json:"username"`
+	DisplayName    string    `json:"display_name"`
+	Bio            string    `json:"bio"`
+	AvatarUrl      string    `json:"avatar_url"`
+	Protected      bool      `json:"protected"`
+	FollowerCount  int64     `json:"follower_count"`
+	FollowingCount int64     `json:"following_count"`
+	ChirpCount     int64     `json:"chirp_count"`
+}
+
+func (q *Queries) GetPublicProfile(ctx context.Context, username string) (GetPublicProfileRow, error) {
+	row := q.db.QueryRowContext(ctx, getPublicProfile, username)
+	var i GetPublicProfileRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Protected,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.ChirpCount,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, created_at, updated_at, email, hashed_password FROM users WHERE email = $1
+SELECT id, created_at, updated_at, email, hashed_password, username, display_name, bio, avatar_url, role, suspended_at, suspended_until, suspension_reason, suspension_hides_chirps, protected FROM users WHERE email = $1
 `
 
 func (q *Queries) GetUser(ctx context.Context, email string) (User, error) {
@@ -69,10 +135,144 @@ func (q *Queries) GetUser(ctx context.Context, email string) (User, error) {
 		&i.UpdatedAt,
 		&i.Email,
 		&i.HashedPassword,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Role,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.SuspensionHidesChirps,
+		&i.Protected,
 	)
 	return i, err
 }
 
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, hashed_password, username, display_name, bio, avatar_url, role, suspended_at, suspended_until, suspension_reason, suspension_hides_chirps, protected FROM users WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Role,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.SuspensionHidesChirps,
+		&i.Protected,
+	)
+	return i, err
+}
+
+const getUserByUsername = `-- name: GetUserByUsername :one
+SELECT id, created_at, updated_at, email, hashed_password, username, display_name, bio, avatar_url, role, suspended_at, suspended_until, suspension_reason, suspension_hides_chirps, protected FROM users WHERE lower(username) = lower($1)
+`
+
+func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByUsername, username)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Role,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.SuspensionHidesChirps,
+		&i.Protected,
+	)
+	return i, err
+}
+
+const getUsersByUsernames = `-- name: GetUsersByUsernames :many
+SELECT id, created_at, updated_at, email, hashed_password, username, display_name, bio, avatar_url, role, suspended_at, suspended_until, suspension_reason, suspension_hides_chirps, protected FROM users WHERE lower(username) = ANY($1::text[])
+`
+
+func (q *Queries) GetUsersByUsernames(ctx context.Context, usernames []string) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersByUsernames, pq.Array(usernames))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.Username,
+			&i.DisplayName,
+			&i.Bio,
+			&i.AvatarUrl,
+			&i.Role,
+			&i.SuspendedAt,
+			&i.SuspendedUntil,
+			&i.SuspensionReason,
+			&i.SuspensionHidesChirps,
+			&i.Protected,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockUsersWithRole = `-- name: LockUsersWithRole :many
+SELECT id FROM users WHERE role = $1 ORDER BY id FOR UPDATE
+`
+
+func (q *Queries) LockUsersWithRole(ctx context.Context, role string) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, lockUsersWithRole, role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const resetUsers = `-- name: ResetUsers :exec
 DELETE FROM users
 `
@@ -81,3 +281,162 @@ func (q *Queries) ResetUsers(ctx context.Context) error {
 	_, err := q.db.ExecContext(ctx, resetUsers)
 	return err
 }
+
+const setUserRole = `-- name: SetUserRole :one
+UPDATE users SET role = $2, updated_at = now() WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, username, display_name, bio, avatar_url, role, suspended_at, suspended_until, suspension_reason, suspension_hides_chirps, protected
+`
+
+type SetUserRoleParams struct {
+	ID   uuid.UUID `json:"id"`
+	Role string    `json:"role"`
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserRole, arg.ID, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Role,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.SuspensionHidesChirps,
+		&i.Protected,
+	)
+	return i, err
+}
+
+const suspendUser = `-- name: SuspendUser :one
+UPDATE users SET suspended_at = now(), suspended_until = $2, suspension_reason = $3, suspension_hides_chirps = $4, updated_at = now()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, username, display_name, bio, avatar_url, role, suspended_at, suspended_until, suspension_reason, suspension_hides_chirps, protected
+`
+
+type SuspendUserParams struct {
+	ID                    uuid.UUID    `json:"id"`
+	SuspendedUntil        sql.NullTime `json:"suspended_until"`
+	SuspensionReason      string       `json:"suspension_reason"`
+	SuspensionHidesChirps bool         `json:"suspension_hides_chirps"`
+}
+
+func (q *Queries) SuspendUser(ctx context.Context, arg SuspendUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, suspendUser,
+		arg.ID,
+		arg.SuspendedUntil,
+		arg.SuspensionReason,
+		arg.SuspensionHidesChirps,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Role,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.SuspensionHidesChirps,
+		&i.Protected,
+	)
+	return i, err
+}
+
+const unsuspendUser = `-- name: UnsuspendUser :one
+UPDATE users SET suspended_at = NULL, suspended_until = NULL, suspension_reason = '', suspension_hides_chirps = false, updated_at = now()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, username, display_name, bio, avatar_url, role, suspended_at, suspended_until, suspension_reason, suspension_hides_chirps, protected
+`
+
+func (q *Queries) UnsuspendUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, unsuspendUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Role,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.SuspensionHidesChirps,
+		&i.Protected,
+	)
+	return i, err
+}
+
+const updateProfile = `-- name: UpdateProfile :one
+UPDATE users SET display_name = $2, bio = $3, avatar_url = $4, updated_at = now() WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, username, display_name, bio, avatar_url, role, suspended_at, suspended_until, suspension_reason, suspension_hides_chirps, protected
+`
+
+type UpdateProfileParams struct {
+	ID          uuid.UUID `json:"id"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	AvatarUrl   string    `json:"avatar_url"`
+	Protected   bool      `json:"protected"`
+}
+
+func (q *Queries) UpdateProfile(ctx context.Context, arg UpdateProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateProfile,
+		arg.ID,
+		arg.DisplayName,
+		arg.Bio,
+		arg.AvatarUrl,
+		arg.Protected,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Role,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.SuspensionHidesChirps,
+		&i.Protected,
+	)
+	return i, err
+}
+
+const updateUsername = `-- name: UpdateUsername :exec
+UPDATE users SET username = $2, updated_at = now() WHERE id = $1
+`
+
+type UpdateUsernameParams struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+}
+
+func (q *Queries) UpdateUsername(ctx context.Context, arg UpdateUsernameParams) error {
+	_, err := q.db.ExecContext(ctx, updateUsername, arg.ID, arg.Username)
+	return err
+}
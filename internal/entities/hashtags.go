@@ -0,0 +1,42 @@
+package entities
+
+import (
+  "regexp"
+  "strings"
+)
+
+const maxHashtagLength = 100
+
+// a hashtag starts at the beginning of the text or after a character that
+// can't be part of a word, so "a#b" and "&#39;" don't count
+var hashtagRegexp = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&])#([\p{L}\p{N}_]+)`)
+
+var tagRegexp = regexp.MustCompile(`^[\p{L}\p{N}_]+$`)
+
+var digitsRegexp = regexp.MustCompile(`^\p{N}+$`)
+
+// Hashtags returns the distinct tags in body, lowercased and without the
+// leading '#', in the order they first appear.
+func Hashtags(body string) []string {
+  tags := []string{}
+  seen := map[string]bool{}
+  for _, match := range hashtagRegexp.FindAllStringSubmatch(body, -1) {
+    tag := NormalizeHashtag(match[1])
+    if tag == "" || seen[tag] {
+      continue
+    }
+    seen[tag] = true
+    tags = append(tags, tag)
+  }
+  return tags
+}
+
+// NormalizeHashtag lowercases tag and drops a leading '#'. It returns "" for
+// anything that isn't a valid tag, such as "#2024" or an overly long tag.
+func NormalizeHashtag(tag string) string {
+  tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+  if len(tag) > maxHashtagLength || !tagRegexp.MatchString(tag) || digitsRegexp.MatchString(tag) {
+    return ""
+  }
+  return tag
+}
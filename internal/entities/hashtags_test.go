@@ -0,0 +1,38 @@
+package entities
+
+import (
+  "slices"
+  "testing"
+)
+
+func TestHashtags(t *testing.T) {
+  cases := map[string][]string{
+    "no tags here":                 {},
+    "#Go is #fun":                  {"go", "fun"},
+    "#go #GO #Go":                  {"go"},
+    "email@x.com a#b &#39; #2024":  {},
+    "(#parens) and #snake_case!":   {"parens", "snake_case"},
+    "#café":                        {"café"},
+  }
+  for body, expected := range cases {
+    tags := Hashtags(body)
+    if !slices.Equal(tags, expected) {
+      t.Errorf("incorrect hashtags for %q, expected %v, got %v", body, expected, tags)
+    }
+  }
+}
+
+func TestNormalizeHashtag(t *testing.T) {
+  cases := map[string]string{
+    "#Chirpy":  "chirpy",
+    "chirpy":   "chirpy",
+    "#2024":    "",
+    "#":        "",
+    "two words": "",
+  }
+  for tag, expected := range cases {
+    if res := NormalizeHashtag(tag); res != expected {
+      t.Errorf("incorrect tag for %q, expected %q, got %q", tag, expected, res)
+    }
+  }
+}
@@ -0,0 +1,38 @@
+package entities
+
+import (
+  "regexp"
+  "unicode/utf8"
+)
+
+var usernameRegexp = regexp.MustCompile(`^[A-Za-z0-9_]{3,30}$`)
+
+// a mention can't follow a word character, '@' or '.', so email addresses
+// aren't picked up as mentions
+var mentionRegexp = regexp.MustCompile(`(?:^|[^A-Za-z0-9_@.])(@([A-Za-z0-9_]{3,30}))\b`)
+
+// Mention is an @username in a chirp body. Start and End are offsets in
+// characters (not bytes) covering the '@' and the username.
+type Mention struct {
+  Username  string
+  Start     int
+  End       int
+}
+
+func ValidUsername(username string) bool {
+  return usernameRegexp.MatchString(username)
+}
+
+// Mentions returns every @username in body in the order they appear.
+func Mentions(body string) []Mention {
+  mentions := []Mention{}
+  for _, match := range mentionRegexp.FindAllStringSubmatchIndex(body, -1) {
+    start := utf8.RuneCountInString(body[:match[2]])
+    mentions = append(mentions, Mention{
+      Username: body[match[4]:match[5]],
+      Start: start,
+      End: start + utf8.RuneCountInString(body[match[2]:match[3]]),
+    })
+  }
+  return mentions
+}
@@ -0,0 +1,36 @@
+package entities
+
+import (
+  "slices"
+  "testing"
+)
+
+func TestMentions(t *testing.T) {
+  cases := map[string][]Mention{
+    "no mentions":              {},
+    "@alice hi":                {{"alice", 0, 6}},
+    "hi @bob and @carol_1!":    {{"bob", 3, 7}, {"carol_1", 12, 20}},
+    "mail me@example.com":      {},
+    "@ab is too short":         {},
+    "café @dave":               {{"dave", 5, 10}},
+  }
+  for body, expected := range cases {
+    mentions := Mentions(body)
+    if !slices.Equal(mentions, expected) {
+      t.Errorf("incorrect mentions for %q, expected %v, got %v", body, expected, mentions)
+    }
+  }
+}
+
+func TestValidUsername(t *testing.T) {
+  for _, username := range []string{"bob", "Alice_99"} {
+    if !ValidUsername(username) {
+      t.Errorf("expected %q to be a valid username", username)
+    }
+  }
+  for _, username := range []string{"", "ab", "has space", "dash-ed", "way_too_long_for_a_username_really"} {
+    if ValidUsername(username) {
+      t.Errorf("expected %q to be an invalid username", username)
+    }
+  }
+}
@@ -0,0 +1,108 @@
+package imaging
+
+import (
+  "image"
+  "math"
+  "strings"
+)
+
+const base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// Blurhash encodes img as a BlurHash (https://blurha.sh) with xComponents by
+// yComponents DCT components, each between 1 and 9.
+func Blurhash(img image.Image, xComponents, yComponents int) string {
+  src := toRGBA(img)
+  w, h := src.Bounds().Dx(), src.Bounds().Dy()
+
+  // linearise once up front rather than once per component
+  linear := make([][3]float64, w*h)
+  for y := 0; y < h; y++ {
+    for x := 0; x < w; x++ {
+      p := src.Pix[y*src.Stride+x*4:]
+      linear[y*w+x] = [3]float64{sRGBToLinear(p[0]), sRGBToLinear(p[1]), sRGBToLinear(p[2])}
+    }
+  }
+
+  factors := make([][3]float64, 0, xComponents*yComponents)
+  for j := 0; j < yComponents; j++ {
+    for i := 0; i < xComponents; i++ {
+      normalisation := 2.0
+      if i == 0 && j == 0 {
+        normalisation = 1
+      }
+
+      var factor [3]float64
+      for y := 0; y < h; y++ {
+        for x := 0; x < w; x++ {
+          basis := normalisation *
+            math.Cos(math.Pi*float64(i)*float64(x)/float64(w)) *
+            math.Cos(math.Pi*float64(j)*float64(y)/float64(h))
+          pixel := linear[y*w+x]
+          factor[0] += basis * pixel[0]
+          factor[1] += basis * pixel[1]
+          factor[2] += basis * pixel[2]
+        }
+      }
+      scale := 1 / float64(w*h)
+      factors = append(factors, [3]float64{factor[0] * scale, factor[1] * scale, factor[2] * scale})
+    }
+  }
+
+  var hash strings.Builder
+  hash.WriteString(base83((xComponents-1)+(yComponents-1)*9, 1))
+
+  maximumValue := 1.0
+  if len(factors) > 1 {
+    actualMax := 0.0
+    for _, factor := range factors[1:] {
+      for _, v := range factor {
+        actualMax = math.Max(actualMax, math.Abs(v))
+      }
+    }
+    quantisedMax := int(math.Max(0, math.Min(82, math.Floor(actualMax*166-0.5))))
+    maximumValue = float64(quantisedMax+1) / 166
+    hash.WriteString(base83(quantisedMax, 1))
+  } else {
+    hash.WriteString(base83(0, 1))
+  }
+
+  dc := factors[0]
+  hash.WriteString(base83(linearToSRGB(dc[0])<<16+linearToSRGB(dc[1])<<8+linearToSRGB(dc[2]), 4))
+
+  for _, factor := range factors[1:] {
+    quant := func(v float64) int {
+      return int(math.Max(0, math.Min(18, math.Floor(signPow(v/maximumValue, 0.5)*9+9.5))))
+    }
+    hash.WriteString(base83(quant(factor[0])*19*19+quant(factor[1])*19+quant(factor[2]), 2))
+  }
+  return hash.String()
+}
+
+func base83(value, length int) string {
+  out := make([]byte, length)
+  for i := length - 1; i >= 0; i-- {
+    out[i] = base83Chars[value%83]
+    value /= 83
+  }
+  return string(out)
+}
+
+func sRGBToLinear(value uint8) float64 {
+  v := float64(value) / 255
+  if v <= 0.04045 {
+    return v / 12.92
+  }
+  return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(value float64) int {
+  v := math.Max(0, math.Min(1, value))
+  if v <= 0.0031308 {
+    return int(v*12.92*255 + 0.5)
+  }
+  return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(value, exp float64) float64 {
+  return math.Copysign(math.Pow(math.Abs(value), exp), value)
+}
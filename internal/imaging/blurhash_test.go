@@ -0,0 +1,36 @@
+package imaging
+
+import (
+  "image"
+  "image/color"
+  "image/draw"
+  "testing"
+)
+
+func TestBlurhashSolidColour(t *testing.T) {
+  img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+  draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{255, 0, 0, 255}), image.Point{}, draw.Src)
+
+  // "L" is the 4x3 size flag and "TI:j" is the DC term for pure red
+  hash := Blurhash(img, 4, 3)
+  if hash[:1] != "L" || hash[2:6] != "TI:j" {
+    t.Errorf("incorrect size flag or average colour in %q", hash)
+  }
+}
+
+func TestBlurhashLength(t *testing.T) {
+  img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+  for i := range img.Pix {
+    img.Pix[i] = uint8(i * 7)
+  }
+  cases := map[[2]int]int{
+    {1, 1}: 6,
+    {4, 3}: 28,
+    {9, 9}: 166,
+  }
+  for components, expected := range cases {
+    if hash := Blurhash(img, components[0], components[1]); len(hash) != expected {
+      t.Errorf("expected %d characters for %v components, got %q", expected, components, hash)
+    }
+  }
+}
@@ -0,0 +1,65 @@
+package imaging
+
+import (
+  "encoding/binary"
+)
+
+// MaxGIFFrames caps the frames in an animation. Their pixels also count
+// towards MaxPixels together, since gif.DecodeAll holds every frame at once.
+const MaxGIFFrames = 500
+
+// gifFrames walks the blocks of a GIF without decoding any image data and
+// returns how many frames it has and their total area. Parsing stops quietly
+// at anything malformed; the decoder reports that properly.
+func gifFrames(data []byte) (frames, pixels int) {
+  if len(data) < 13 {
+    return 0, 0
+  }
+  i := 13
+  if data[10]&0x80 != 0 {
+    i += 3 << (data[10]&0x07 + 1)
+  }
+
+  // skipBlocks steps over a run of data sub-blocks ending in an empty one
+  skipBlocks := func() bool {
+    for i < len(data) {
+      n := int(data[i])
+      i += 1 + n
+      if n == 0 {
+        return true
+      }
+    }
+    return false
+  }
+
+  for i < len(data) {
+    switch data[i] {
+    case 0x21:
+      i += 2
+      if !skipBlocks() {
+        return frames, pixels
+      }
+    case 0x2C:
+      if i+10 > len(data) {
+        return frames, pixels
+      }
+      width := int(binary.LittleEndian.Uint16(data[i+5:]))
+      height := int(binary.LittleEndian.Uint16(data[i+7:]))
+      packed := data[i+9]
+      i += 10
+      if packed&0x80 != 0 {
+        i += 3 << (packed&0x07 + 1)
+      }
+      // LZW minimum code size
+      i++
+      frames++
+      pixels += width * height
+      if !skipBlocks() {
+        return frames, pixels
+      }
+    default:
+      return frames, pixels
+    }
+  }
+  return frames, pixels
+}
@@ -0,0 +1,55 @@
+package imaging
+
+import (
+  "bytes"
+  "errors"
+  "image"
+  "image/color/palette"
+  "image/gif"
+  "testing"
+)
+
+func testGIF(t *testing.T, w, h, frames int) []byte {
+  // every frame can share one blank image; only the headers matter here
+  frame := image.NewPaletted(image.Rect(0, 0, w, h), palette.Plan9)
+  anim := &gif.GIF{}
+  for i := 0; i < frames; i++ {
+    anim.Image = append(anim.Image, frame)
+    anim.Delay = append(anim.Delay, 10)
+  }
+  var buf bytes.Buffer
+  if err := gif.EncodeAll(&buf, anim); err != nil {
+    t.Fatalf("error encoding test gif: %v", err)
+  }
+  return buf.Bytes()
+}
+
+func TestGIFFrames(t *testing.T) {
+  frames, pixels := gifFrames(testGIF(t, 40, 30, 3))
+  if frames != 3 || pixels != 3*40*30 {
+    t.Errorf("incorrect frame count, expected 3 frames of 1200 pixels, got %d frames of %d pixels", frames, pixels)
+  }
+
+  processed, err := Process(testGIF(t, 40, 30, 3), "image/gif", DefaultSizes)
+  if err != nil {
+    t.Fatalf("error processing gif: %v", err)
+  }
+  if processed.Original.Width != 40 || processed.Original.Height != 30 {
+    t.Errorf("incorrect dimensions, got %dx%d", processed.Original.Width, processed.Original.Height)
+  }
+}
+
+func TestProcessRejectsLargeGIFs(t *testing.T) {
+  cases := []struct {
+    name  string
+    data  []byte
+  }{
+    {"too many frames", testGIF(t, 1, 1, MaxGIFFrames+1)},
+    {"too many pixels", testGIF(t, 2000, 2000, MaxPixels/(2000*2000)+1)},
+  }
+  for _, c := range cases {
+    if _, err := Process(c.data, "image/gif", DefaultSizes); !errors.Is(err, ErrTooLarge) {
+      t.Errorf("%s: expected ErrTooLarge, got %v", c.name, err)
+    }
+  }
+}
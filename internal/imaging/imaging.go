@@ -0,0 +1,168 @@
+package imaging
+
+import (
+  "bytes"
+  "errors"
+  "image"
+  "image/gif"
+  "image/jpeg"
+  "image/png"
+)
+
+var ErrUnsupported = errors.New("unsupported image format")
+
+var ErrTooLarge = errors.New("image dimensions too large")
+
+// MaxPixels bounds the decoded size so a small, highly compressed upload
+// can't exhaust memory.
+const MaxPixels = 40_000_000
+
+const jpegQuality = 85
+
+// Size is a thumbnail that fits inside a MaxDim square.
+type Size struct {
+  Name   string
+  MaxDim int
+}
+
+var DefaultSizes = []Size{
+  {"small", 320},
+  {"medium", 640},
+  {"large", 1280},
+}
+
+type Encoded struct {
+  Name        string
+  ContentType string
+  Data        []byte
+  Width       int
+  Height      int
+}
+
+// Processed is an upload rebuilt from its pixels alone, so no EXIF, GPS or
+// other metadata survives.
+type Processed struct {
+  Original Encoded
+  Variants []Encoded
+  Blurhash string
+}
+
+// Process re-encodes an image and renders the thumbnails in sizes that are
+// smaller than the original. WebP has no encoder here, so stills come back as
+// PNG; see processWebP.
+func Process(data []byte, contentType string, sizes []Size) (*Processed, error) {
+  switch contentType {
+  case "image/jpeg", "image/png", "image/gif":
+  case "image/webp":
+    return processWebP(data, sizes)
+  default:
+    return nil, ErrUnsupported
+  }
+
+  config, _, err := image.DecodeConfig(bytes.NewReader(data))
+  if err != nil {
+    return nil, err
+  }
+  if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+    return nil, ErrTooLarge
+  }
+
+  var img image.Image
+  var original []byte
+  switch contentType {
+  case "image/jpeg":
+    img, err = jpeg.Decode(bytes.NewReader(data))
+    if err != nil {
+      return nil, err
+    }
+    // the orientation lives in the EXIF we're about to drop, so bake it in
+    img = Orient(img, jpegOrientation(data))
+    original, err = encode(img, contentType)
+  case "image/png":
+    img, err = png.Decode(bytes.NewReader(data))
+    if err != nil {
+      return nil, err
+    }
+    original, err = encode(img, contentType)
+  case "image/gif":
+    // the screen size says nothing about how many frames are behind it
+    if frames, pixels := gifFrames(data); frames > MaxGIFFrames || pixels > MaxPixels {
+      return nil, ErrTooLarge
+    }
+    // keep every frame so animations still play
+    var anim *gif.GIF
+    anim, err = gif.DecodeAll(bytes.NewReader(data))
+    if err != nil {
+      return nil, err
+    }
+    if len(anim.Image) == 0 {
+      return nil, ErrUnsupported
+    }
+    img = anim.Image[0]
+    var buf bytes.Buffer
+    err = gif.EncodeAll(&buf, anim)
+    original = buf.Bytes()
+  }
+  if err != nil {
+    return nil, err
+  }
+
+  // thumbnails of a gif are a still of its first frame
+  thumbType := contentType
+  if thumbType == "image/gif" {
+    thumbType = "image/png"
+  }
+  return withThumbnails(img, original, contentType, thumbType, sizes)
+}
+
+// withThumbnails builds the result for an upload already re-encoded as
+// original, rendering thumbnails and a placeholder from img.
+func withThumbnails(img image.Image, original []byte, contentType, thumbType string, sizes []Size) (*Processed, error) {
+  bounds := img.Bounds()
+  processed := &Processed{
+    Original: Encoded{
+      Name: "original",
+      ContentType: contentType,
+      Data: original,
+      Width: bounds.Dx(),
+      Height: bounds.Dy(),
+    },
+    Variants: []Encoded{},
+  }
+
+  for _, size := range sizes {
+    if max(bounds.Dx(), bounds.Dy()) <= size.MaxDim {
+      continue
+    }
+    thumb := Fit(img, size.MaxDim)
+    data, err := encode(thumb, thumbType)
+    if err != nil {
+      return nil, err
+    }
+    processed.Variants = append(processed.Variants, Encoded{
+      Name: size.Name,
+      ContentType: thumbType,
+      Data: data,
+      Width: thumb.Bounds().Dx(),
+      Height: thumb.Bounds().Dy(),
+    })
+  }
+
+  // the placeholder is a handful of colours, so a tiny copy is plenty
+  processed.Blurhash = Blurhash(Fit(img, 32), 4, 3)
+  return processed, nil
+}
+
+func encode(img image.Image, contentType string) ([]byte, error) {
+  var buf bytes.Buffer
+  var err error
+  switch contentType {
+  case "image/jpeg":
+    err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+  case "image/png":
+    err = png.Encode(&buf, img)
+  default:
+    err = ErrUnsupported
+  }
+  return buf.Bytes(), err
+}
@@ -0,0 +1,133 @@
+package imaging
+
+import (
+  "bytes"
+  "encoding/binary"
+  "image"
+  "image/color"
+  "image/jpeg"
+  "testing"
+)
+
+func testJPEG(t *testing.T, w, h int) []byte {
+  img := image.NewRGBA(image.Rect(0, 0, w, h))
+  for y := 0; y < h; y++ {
+    for x := 0; x < w; x++ {
+      img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+    }
+  }
+  var buf bytes.Buffer
+  if err := jpeg.Encode(&buf, img, nil); err != nil {
+    t.Fatalf("error encoding test image: %v", err)
+  }
+  return buf.Bytes()
+}
+
+// withExif splices an APP1 segment carrying an orientation tag and a fake
+// GPS string in after the SOI marker.
+func withExif(data []byte, orientation uint16) []byte {
+  tiff := []byte("II*\x00\x08\x00\x00\x00")
+  tiff = binary.LittleEndian.AppendUint16(tiff, 1)
+  tiff = binary.LittleEndian.AppendUint16(tiff, 0x0112)
+  tiff = binary.LittleEndian.AppendUint16(tiff, 3)
+  tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+  tiff = binary.LittleEndian.AppendUint16(tiff, orientation)
+  tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+  tiff = append(tiff, "GPS 51.5074N 0.1278W"...)
+
+  segment := append([]byte("Exif\x00\x00"), tiff...)
+  out := []byte{0xFF, 0xD8, 0xFF, 0xE1}
+  out = binary.BigEndian.AppendUint16(out, uint16(len(segment)+2))
+  out = append(out, segment...)
+  return append(out, data[2:]...)
+}
+
+func TestProcessStripsMetadata(t *testing.T) {
+  data := withExif(testJPEG(t, 800, 400), 1)
+  if !bytes.Contains(data, []byte("GPS")) {
+    t.Fatalf("test image is missing its metadata")
+  }
+
+  processed, err := Process(data, "image/jpeg", DefaultSizes)
+  if err != nil {
+    t.Fatalf("error processing image: %v", err)
+  }
+  if bytes.Contains(processed.Original.Data, []byte("Exif")) || bytes.Contains(processed.Original.Data, []byte("GPS")) {
+    t.Errorf("metadata survived re-encoding")
+  }
+  if processed.Original.Width != 800 || processed.Original.Height != 400 {
+    t.Errorf("incorrect dimensions, got %dx%d", processed.Original.Width, processed.Original.Height)
+  }
+
+  // large is bigger than the original, so only two thumbnails are made
+  expected := map[string][2]int{"small": {320, 160}, "medium": {640, 320}}
+  if len(processed.Variants) != len(expected) {
+    t.Fatalf("expected %d variants, got %d", len(expected), len(processed.Variants))
+  }
+  for _, variant := range processed.Variants {
+    dims := expected[variant.Name]
+    if variant.Width != dims[0] || variant.Height != dims[1] {
+      t.Errorf("incorrect %s dimensions, expected %v, got %dx%d", variant.Name, dims, variant.Width, variant.Height)
+    }
+    config, err := jpeg.DecodeConfig(bytes.NewReader(variant.Data))
+    if err != nil || config.Width != dims[0] || config.Height != dims[1] {
+      t.Errorf("%s does not decode to its dimensions: %v", variant.Name, err)
+    }
+  }
+
+  if len(processed.Blurhash) != 28 {
+    t.Errorf("expected a 4x3 blurhash, got %q", processed.Blurhash)
+  }
+}
+
+func TestProcessAppliesOrientation(t *testing.T) {
+  processed, err := Process(withExif(testJPEG(t, 60, 20), 6), "image/jpeg", nil)
+  if err != nil {
+    t.Fatalf("error processing image: %v", err)
+  }
+  if processed.Original.Width != 20 || processed.Original.Height != 60 {
+    t.Errorf("expected rotated 20x60, got %dx%d", processed.Original.Width, processed.Original.Height)
+  }
+}
+
+func TestProcessRejects(t *testing.T) {
+  if _, err := Process([]byte("not an image"), "video/mp4", nil); err != ErrUnsupported {
+    t.Errorf("expected ErrUnsupported, got %v", err)
+  }
+  if _, err := Process([]byte("not an image"), "image/png", nil); err == nil {
+    t.Errorf("expected garbage to fail to decode")
+  }
+}
+
+func TestOrient(t *testing.T) {
+  // a 2x1 image: red then blue
+  img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+  red := color.RGBA{255, 0, 0, 255}
+  blue := color.RGBA{0, 0, 255, 255}
+  img.Set(0, 0, red)
+  img.Set(1, 0, blue)
+
+  cases := map[int][]color.RGBA{
+    1: {red, blue},
+    2: {blue, red},
+    3: {blue, red},
+    6: {red, blue},
+    8: {blue, red},
+  }
+  for orientation, expected := range cases {
+    res := toRGBA(Orient(img, orientation))
+    var got []color.RGBA
+    bounds := res.Bounds()
+    for y := 0; y < bounds.Dy(); y++ {
+      for x := 0; x < bounds.Dx(); x++ {
+        got = append(got, res.RGBAAt(x, y))
+      }
+    }
+    if len(got) != len(expected) || got[0] != expected[0] || got[1] != expected[1] {
+      t.Errorf("incorrect pixels for orientation %d, expected %v, got %v", orientation, expected, got)
+    }
+    if orientation >= 5 && bounds.Dx() != 1 {
+      t.Errorf("orientation %d should swap dimensions, got %v", orientation, bounds)
+    }
+  }
+}
@@ -0,0 +1,107 @@
+package imaging
+
+import (
+  "bytes"
+  "encoding/binary"
+  "image"
+)
+
+// Orient applies an EXIF orientation (1-8) so the pixels display upright
+// without the tag.
+func Orient(img image.Image, orientation int) image.Image {
+  if orientation < 2 || orientation > 8 {
+    return img
+  }
+
+  src := toRGBA(img)
+  w, h := src.Bounds().Dx(), src.Bounds().Dy()
+  dw, dh := w, h
+  if orientation >= 5 {
+    dw, dh = h, w
+  }
+  dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+  for y := 0; y < dh; y++ {
+    for x := 0; x < dw; x++ {
+      var sx, sy int
+      switch orientation {
+      case 2:
+        sx, sy = w-1-x, y
+      case 3:
+        sx, sy = w-1-x, h-1-y
+      case 4:
+        sx, sy = x, h-1-y
+      case 5:
+        sx, sy = y, x
+      case 6:
+        sx, sy = y, h-1-x
+      case 7:
+        sx, sy = w-1-y, h-1-x
+      case 8:
+        sx, sy = w-1-y, x
+      }
+      copy(dst.Pix[y*dst.Stride+x*4:y*dst.Stride+x*4+4], src.Pix[sy*src.Stride+sx*4:])
+    }
+  }
+  return dst
+}
+
+// jpegOrientation reads the orientation tag out of a JPEG's EXIF segment,
+// returning 1 when there isn't one.
+func jpegOrientation(data []byte) int {
+  if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+    return 1
+  }
+
+  for i := 2; i+4 <= len(data); {
+    if data[i] != 0xFF {
+      return 1
+    }
+    marker := data[i+1]
+    // start of scan: no more metadata segments after this
+    if marker == 0xDA {
+      return 1
+    }
+    length := int(binary.BigEndian.Uint16(data[i+2:]))
+    if length < 2 || i+2+length > len(data) {
+      return 1
+    }
+    segment := data[i+4 : i+2+length]
+    if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+      return tiffOrientation(segment[6:])
+    }
+    i += 2 + length
+  }
+  return 1
+}
+
+func tiffOrientation(tiff []byte) int {
+  if len(tiff) < 8 {
+    return 1
+  }
+  var order binary.ByteOrder
+  switch string(tiff[:2]) {
+  case "II":
+    order = binary.LittleEndian
+  case "MM":
+    order = binary.BigEndian
+  default:
+    return 1
+  }
+
+  ifd := int(order.Uint32(tiff[4:]))
+  if ifd < 8 || ifd+2 > len(tiff) {
+    return 1
+  }
+  count := int(order.Uint16(tiff[ifd:]))
+  for i := 0; i < count; i++ {
+    entry := ifd + 2 + i*12
+    if entry+12 > len(tiff) {
+      return 1
+    }
+    if order.Uint16(tiff[entry:]) == 0x0112 {
+      return int(order.Uint16(tiff[entry+8:]))
+    }
+  }
+  return 1
+}
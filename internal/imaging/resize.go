@@ -0,0 +1,71 @@
+package imaging
+
+import (
+  "image"
+  "image/draw"
+)
+
+// Fit scales img down to fit inside a maxDim square, keeping its aspect
+// ratio. Images that already fit are returned as they are.
+func Fit(img image.Image, maxDim int) image.Image {
+  bounds := img.Bounds()
+  w, h := bounds.Dx(), bounds.Dy()
+  if w <= maxDim && h <= maxDim {
+    return img
+  }
+
+  dw, dh := maxDim, maxDim
+  if w > h {
+    dh = max(1, h*maxDim/w)
+  } else {
+    dw = max(1, w*maxDim/h)
+  }
+  return Resize(img, dw, dh)
+}
+
+// Resize downsamples img to dw x dh by averaging the source pixels that
+// fall under each destination pixel.
+func Resize(img image.Image, dw, dh int) *image.RGBA {
+  src := toRGBA(img)
+  sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+  dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+  for y := 0; y < dh; y++ {
+    y0, y1 := y*sh/dh, max((y+1)*sh/dh, y*sh/dh+1)
+    for x := 0; x < dw; x++ {
+      x0, x1 := x*sw/dw, max((x+1)*sw/dw, x*sw/dw+1)
+
+      var r, g, b, a, n uint64
+      for sy := y0; sy < y1; sy++ {
+        row := src.Pix[sy*src.Stride:]
+        for sx := x0; sx < x1; sx++ {
+          p := row[sx*4 : sx*4+4]
+          r += uint64(p[0])
+          g += uint64(p[1])
+          b += uint64(p[2])
+          a += uint64(p[3])
+          n++
+        }
+      }
+
+      d := dst.Pix[y*dst.Stride+x*4:]
+      d[0] = uint8(r / n)
+      d[1] = uint8(g / n)
+      d[2] = uint8(b / n)
+      d[3] = uint8(a / n)
+    }
+  }
+  return dst
+}
+
+// toRGBA copies img into a zero-origin RGBA so its pixels can be indexed
+// directly.
+func toRGBA(img image.Image) *image.RGBA {
+  if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+    return rgba
+  }
+  bounds := img.Bounds()
+  rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+  draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+  return rgba
+}
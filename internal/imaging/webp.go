@@ -0,0 +1,156 @@
+package imaging
+
+import (
+  "bytes"
+  "encoding/binary"
+  "errors"
+
+  "golang.org/x/image/webp"
+)
+
+var errBadWebP = errors.New("malformed webp")
+
+// processWebP decodes a still WebP and re-encodes it as PNG, which keeps any
+// transparency, with thumbnails and a placeholder like every other image.
+// There's no WebP encoder to write it back with. Animated WebP can't be
+// decoded at all, so it keeps playing by only having its EXIF and XMP chunks
+// dropped, and gets no thumbnails or placeholder.
+func processWebP(data []byte, sizes []Size) (*Processed, error) {
+  stripped, err := StripWebPMetadata(data)
+  if err != nil {
+    return nil, err
+  }
+  width, height, err := webpDimensions(stripped)
+  if err != nil {
+    return nil, err
+  }
+  if width*height > MaxPixels {
+    return nil, ErrTooLarge
+  }
+
+  if !webpAnimated(stripped) {
+    img, err := webp.Decode(bytes.NewReader(stripped))
+    if err != nil {
+      return nil, err
+    }
+    original, err := encode(img, "image/png")
+    if err != nil {
+      return nil, err
+    }
+    return withThumbnails(img, original, "image/png", "image/png", sizes)
+  }
+
+  return &Processed{
+    Original: Encoded{
+      Name: "original",
+      ContentType: "image/webp",
+      Data: stripped,
+      Width: width,
+      Height: height,
+    },
+    Variants: []Encoded{},
+  }, nil
+}
+
+type riffChunk struct {
+  fourCC  string
+  payload []byte
+}
+
+func webpChunks(data []byte) ([]riffChunk, error) {
+  if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+    return nil, errBadWebP
+  }
+
+  chunks := []riffChunk{}
+  for i := 12; i < len(data); {
+    if i+8 > len(data) {
+      return nil, errBadWebP
+    }
+    size := int(binary.LittleEndian.Uint32(data[i+4:]))
+    if size < 0 || i+8+size > len(data) {
+      return nil, errBadWebP
+    }
+    chunks = append(chunks, riffChunk{string(data[i : i+4]), data[i+8 : i+8+size]})
+    // chunks are padded to an even length
+    i += 8 + size + size%2
+  }
+  return chunks, nil
+}
+
+// StripWebPMetadata rewrites a WebP file without its EXIF and XMP chunks.
+func StripWebPMetadata(data []byte) ([]byte, error) {
+  chunks, err := webpChunks(data)
+  if err != nil {
+    return nil, err
+  }
+
+  out := []byte("RIFF\x00\x00\x00\x00WEBP")
+  for _, chunk := range chunks {
+    if chunk.fourCC == "EXIF" || chunk.fourCC == "XMP " {
+      continue
+    }
+    payload := chunk.payload
+    if chunk.fourCC == "VP8X" && len(payload) > 0 {
+      // clear the flags that announce the chunks we dropped
+      payload = append([]byte{}, payload...)
+      payload[0] &^= 0x08 | 0x04
+    }
+    out = append(out, chunk.fourCC...)
+    out = binary.LittleEndian.AppendUint32(out, uint32(len(payload)))
+    out = append(out, payload...)
+    if len(payload)%2 == 1 {
+      out = append(out, 0)
+    }
+  }
+  binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+  return out, nil
+}
+
+// webpAnimated reports whether the VP8X header announces an animation.
+func webpAnimated(data []byte) bool {
+  chunks, err := webpChunks(data)
+  if err != nil {
+    return false
+  }
+  for _, chunk := range chunks {
+    if chunk.fourCC == "VP8X" && len(chunk.payload) > 0 {
+      return chunk.payload[0]&0x02 != 0
+    }
+  }
+  return false
+}
+
+func webpDimensions(data []byte) (int, int, error) {
+  chunks, err := webpChunks(data)
+  if err != nil {
+    return 0, 0, err
+  }
+
+  for _, chunk := range chunks {
+    p := chunk.payload
+    switch chunk.fourCC {
+    case "VP8X":
+      if len(p) < 10 {
+        return 0, 0, errBadWebP
+      }
+      width := int(p[4]) | int(p[5])<<8 | int(p[6])<<16
+      height := int(p[7]) | int(p[8])<<8 | int(p[9])<<16
+      return width + 1, height + 1, nil
+    case "VP8 ":
+      if len(p) < 10 || p[3] != 0x9d || p[4] != 0x01 || p[5] != 0x2a {
+        return 0, 0, errBadWebP
+      }
+      width := int(binary.LittleEndian.Uint16(p[6:]) & 0x3fff)
+      height := int(binary.LittleEndian.Uint16(p[8:]) & 0x3fff)
+      return width, height, nil
+    case "VP8L":
+      if len(p) < 5 || p[0] != 0x2f {
+        return 0, 0, errBadWebP
+      }
+      bits := binary.LittleEndian.Uint32(p[1:])
+      return int(bits&0x3fff) + 1, int(bits>>14&0x3fff) + 1, nil
+    }
+  }
+  return 0, 0, errBadWebP
+}
@@ -0,0 +1,156 @@
+package imaging
+
+import (
+  "bytes"
+  "encoding/binary"
+  "image/png"
+  "testing"
+)
+
+func webpChunk(fourCC string, payload []byte) []byte {
+  out := binary.LittleEndian.AppendUint32([]byte(fourCC), uint32(len(payload)))
+  out = append(out, payload...)
+  if len(payload)%2 == 1 {
+    out = append(out, 0)
+  }
+  return out
+}
+
+func testWebP() []byte {
+  // VP8X for a 300x200 canvas with the EXIF and XMP flags set
+  vp8x := []byte{0x08 | 0x04, 0, 0, 0, 43, 1, 0, 199, 0, 0}
+  body := []byte("WEBP")
+  body = append(body, webpChunk("VP8X", vp8x)...)
+  body = append(body, webpChunk("VP8L", []byte{0x2f, 0, 0, 0, 0})...)
+  body = append(body, webpChunk("EXIF", []byte("GPS 51.5074N"))...)
+  body = append(body, webpChunk("XMP ", []byte("<x:xmpmeta/>!"))...)
+  return append(binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(len(body))), body...)
+}
+
+// solidVP8L is a lossless bitstream for a w x h image of one colour. Every
+// prefix code has a single symbol, so the pixels themselves take no bits.
+func solidVP8L(w, h int, r, g, b, a uint8) []byte {
+  var out []byte
+  var acc uint64
+  var n uint
+  put := func(v uint64, bits uint) {
+    acc |= v << n
+    n += bits
+    for n >= 8 {
+      out = append(out, byte(acc))
+      acc >>= 8
+      n -= 8
+    }
+  }
+  // single symbol code: simple, one symbol, eight bit symbol
+  code := func(symbol uint8) {
+    put(1, 1)
+    put(0, 1)
+    put(1, 1)
+    put(uint64(symbol), 8)
+  }
+
+  put(0x2f, 8)
+  put(uint64(w-1), 14)
+  put(uint64(h-1), 14)
+  put(1, 1)
+  put(0, 3)
+  // no transforms, colour cache or meta prefix codes
+  put(0, 1)
+  put(0, 1)
+  put(0, 1)
+  code(g)
+  code(r)
+  code(b)
+  code(a)
+  code(0)
+  put(0, 7)
+  return out
+}
+
+func TestProcessWebP(t *testing.T) {
+  body := []byte("WEBP")
+  body = append(body, webpChunk("VP8X", []byte{0x08, 0, 0, 0, 0xe7, 0x03, 0, 0x57, 0x02, 0})...)
+  body = append(body, webpChunk("VP8L", solidVP8L(1000, 600, 200, 100, 50, 255))...)
+  body = append(body, webpChunk("EXIF", []byte("GPS 51.5074N"))...)
+  data := append(binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(len(body))), body...)
+
+  processed, err := Process(data, "image/webp", DefaultSizes)
+  if err != nil {
+    t.Fatalf("error processing webp: %v", err)
+  }
+  if processed.Original.ContentType != "image/png" || bytes.Contains(processed.Original.Data, []byte("GPS")) {
+    t.Errorf("expected a clean png original, got %s", processed.Original.ContentType)
+  }
+  img, err := png.Decode(bytes.NewReader(processed.Original.Data))
+  if err != nil {
+    t.Fatalf("original is not a valid png: %v", err)
+  }
+  if r, g, b, _ := img.At(500, 300).RGBA(); r>>8 != 200 || g>>8 != 100 || b>>8 != 50 {
+    t.Errorf("incorrect pixel, got %d,%d,%d", r>>8, g>>8, b>>8)
+  }
+  if processed.Original.Width != 1000 || processed.Original.Height != 600 {
+    t.Errorf("incorrect dimensions, got %dx%d", processed.Original.Width, processed.Original.Height)
+  }
+  // large is bigger than the original, so only small and medium are made
+  if len(processed.Variants) != 2 || processed.Variants[0].ContentType != "image/png" {
+    t.Errorf("expected small and medium png thumbnails, got %d", len(processed.Variants))
+  }
+  if processed.Blurhash == "" {
+    t.Errorf("expected a blurhash")
+  }
+}
+
+func TestProcessAnimatedWebP(t *testing.T) {
+  // an animation flag in VP8X keeps the stripped original as it is
+  body := []byte("WEBP")
+  body = append(body, webpChunk("VP8X", []byte{0x02 | 0x08, 0, 0, 0, 43, 1, 0, 199, 0, 0})...)
+  body = append(body, webpChunk("ANIM", make([]byte, 6))...)
+  body = append(body, webpChunk("EXIF", []byte("GPS 51.5074N"))...)
+  data := append(binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(len(body))), body...)
+
+  processed, err := Process(data, "image/webp", DefaultSizes)
+  if err != nil {
+    t.Fatalf("error processing webp: %v", err)
+  }
+  if processed.Original.ContentType != "image/webp" || bytes.Contains(processed.Original.Data, []byte("GPS")) {
+    t.Errorf("expected a stripped webp original, got %s", processed.Original.ContentType)
+  }
+  if len(processed.Variants) != 0 || processed.Blurhash != "" {
+    t.Errorf("expected no thumbnails or placeholder for an animation")
+  }
+}
+
+func TestStripWebPMetadata(t *testing.T) {
+  stripped, err := StripWebPMetadata(testWebP())
+  if err != nil {
+    t.Fatalf("error stripping webp: %v", err)
+  }
+  if bytes.Contains(stripped, []byte("GPS")) || bytes.Contains(stripped, []byte("xmpmeta")) {
+    t.Errorf("metadata survived stripping")
+  }
+
+  chunks, err := webpChunks(stripped)
+  if err != nil {
+    t.Fatalf("stripped webp is malformed: %v", err)
+  }
+  if len(chunks) != 2 || chunks[0].payload[0] != 0 {
+    t.Errorf("expected VP8X with cleared flags and VP8L, got %v", chunks)
+  }
+  if size := binary.LittleEndian.Uint32(stripped[4:]); int(size) != len(stripped)-8 {
+    t.Errorf("incorrect RIFF size %d for %d bytes", size, len(stripped))
+  }
+
+  width, height, err := webpDimensions(stripped)
+  if err != nil || width != 300 || height != 200 {
+    t.Errorf("expected 300x200, got %dx%d (%v)", width, height, err)
+  }
+}
+
+func TestStripWebPMetadataRejectsGarbage(t *testing.T) {
+  bad := testWebP()
+  binary.LittleEndian.PutUint32(bad[16:], 1000)
+  if _, err := StripWebPMetadata(bad); err == nil {
+    t.Errorf("expected an overlong chunk to be rejected")
+  }
+}
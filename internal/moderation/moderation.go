@@ -0,0 +1,79 @@
+package moderation
+
+import (
+  "fmt"
+  "strings"
+)
+
+// Action is what happens to a chirp that matches a rule. Later actions are
+// stronger, and a chain keeps the strongest one that fired.
+type Action int
+
+const (
+  Allow Action = iota
+  Mask
+  Hold
+  Reject
+)
+
+func (a Action) String() string {
+  switch a {
+  case Mask:
+    return "mask"
+  case Hold:
+    return "hold"
+  case Reject:
+    return "reject"
+  default:
+    return "allow"
+  }
+}
+
+func ParseAction(s string) (Action, error) {
+  switch strings.ToLower(s) {
+  case "mask":
+    return Mask, nil
+  case "hold":
+    return Hold, nil
+  case "reject":
+    return Reject, nil
+  default:
+    return Allow, fmt.Errorf("unknown moderation action %q", s)
+  }
+}
+
+const maskText = "****"
+
+type Result struct {
+  // Body has every masked match replaced.
+  Body    string
+  Action  Action
+  Matches []string
+}
+
+func (r *Result) add(match string, action Action) {
+  r.Matches = append(r.Matches, match)
+  r.Action = max(r.Action, action)
+}
+
+type Filter interface {
+  Filter(body string) Result
+}
+
+// Chain runs each filter over the output of the last one.
+type Chain []Filter
+
+func (c Chain) Filter(body string) Result {
+  res := Result{Body: body, Matches: []string{}}
+  for _, filter := range c {
+    next := filter.Filter(res.Body)
+    res.Body = next.Body
+    res.Matches = append(res.Matches, next.Matches...)
+    res.Action = max(res.Action, next.Action)
+    // nothing downstream can change a rejection
+    if res.Action == Reject {
+      break
+    }
+  }
+  return res
+}
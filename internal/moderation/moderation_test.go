@@ -0,0 +1,55 @@
+package moderation
+
+import (
+  "slices"
+  "testing"
+)
+
+func TestRegexRule(t *testing.T) {
+  rule, err := NewRegexRule("phone", `\b\d{3}-\d{4}\b`, Mask)
+  if err != nil {
+    t.Fatalf("error compiling rule: %v", err)
+  }
+  res := rule.Filter("call 555-1234 or 555-9876")
+  if res.Body != "call **** or ****" || res.Action != Mask || !slices.Equal(res.Matches, []string{"phone"}) {
+    t.Errorf("incorrect result %+v", res)
+  }
+
+  if _, err = NewRegexRule("bad", `(`, Mask); err == nil {
+    t.Errorf("expected an invalid pattern to fail")
+  }
+}
+
+func TestChain(t *testing.T) {
+  link, _ := NewRegexRule("links", `https?://\S+`, Hold)
+  spam, _ := NewRegexRule("spam", `(?i)buy now`, Reject)
+  chain := Chain{NewWordList(map[string]Action{"fornax": Mask}), link}
+
+  res := chain.Filter("fornax https://example.com")
+  if res.Body != "**** https://example.com" || res.Action != Hold || !slices.Equal(res.Matches, []string{"fornax", "links"}) {
+    t.Errorf("incorrect result %+v", res)
+  }
+
+  res = chain.Filter("nothing to see")
+  if res.Action != Allow || len(res.Matches) != 0 {
+    t.Errorf("expected a clean body to be allowed, got %+v", res)
+  }
+
+  // the strongest action wins and later filters are skipped
+  res = Chain{spam, link}.Filter("BUY NOW http://x.y")
+  if res.Action != Reject || !slices.Equal(res.Matches, []string{"spam"}) {
+    t.Errorf("expected a rejection, got %+v", res)
+  }
+}
+
+func TestParseAction(t *testing.T) {
+  for _, action := range []Action{Mask, Hold, Reject} {
+    parsed, err := ParseAction(action.String())
+    if err != nil || parsed != action {
+      t.Errorf("expected %v to round trip, got %v (%v)", action, parsed, err)
+    }
+  }
+  if _, err := ParseAction("allow"); err == nil {
+    t.Errorf("expected allow to be rejected as a rule action")
+  }
+}
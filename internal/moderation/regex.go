@@ -0,0 +1,33 @@
+package moderation
+
+import (
+  "regexp"
+)
+
+// RegexRule is a named pattern, compiled once when the rule is loaded.
+type RegexRule struct {
+  Name    string
+  Action  Action
+  pattern *regexp.Regexp
+}
+
+func NewRegexRule(name, pattern string, action Action) (*RegexRule, error) {
+  re, err := regexp.Compile(pattern)
+  if err != nil {
+    return nil, err
+  }
+  return &RegexRule{name, action, re}, nil
+}
+
+func (r *RegexRule) Filter(body string) Result {
+  res := Result{Body: body, Matches: []string{}}
+  if !r.pattern.MatchString(body) {
+    return res
+  }
+
+  res.add(r.Name, r.Action)
+  if r.Action == Mask {
+    res.Body = r.pattern.ReplaceAllString(body, maskText)
+  }
+  return res
+}
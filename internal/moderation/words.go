@@ -0,0 +1,111 @@
+package moderation
+
+import (
+  "bufio"
+  "fmt"
+  "io"
+  "strings"
+  "unicode"
+)
+
+// WordList matches whole words case-insensitively, so a listed word
+// inside a longer one is left alone.
+type WordList struct {
+  words map[string]Action
+}
+
+func NewWordList(words map[string]Action) *WordList {
+  list := &WordList{map[string]Action{}}
+  for word, action := range words {
+    list.words[strings.ToLower(word)] = action
+  }
+  return list
+}
+
+// LoadWordList reads one word per line, optionally followed by an action.
+// Words without one are masked, and blank lines and # comments are skipped.
+func LoadWordList(r io.Reader) (*WordList, error) {
+  words := map[string]Action{}
+  scanner := bufio.NewScanner(r)
+  for line := 1; scanner.Scan(); line++ {
+    fields := strings.Fields(scanner.Text())
+    if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+      continue
+    }
+    if len(fields) > 2 {
+      return nil, fmt.Errorf("line %d: expected a word and an optional action", line)
+    }
+
+    if err := CheckWord(fields[0]); err != nil {
+      return nil, fmt.Errorf("line %d: %w", line, err)
+    }
+
+    action := Mask
+    if len(fields) == 2 {
+      var err error
+      if action, err = ParseAction(fields[1]); err != nil {
+        return nil, fmt.Errorf("line %d: %w", line, err)
+      }
+    }
+    words[fields[0]] = action
+  }
+  if err := scanner.Err(); err != nil {
+    return nil, err
+  }
+  return NewWordList(words), nil
+}
+
+func isWordRune(r rune) bool {
+  return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// CheckWord makes sure a list entry can match. Bodies are split into runs of
+// letters and digits, so an entry with anything else in it never would.
+func CheckWord(word string) error {
+  for _, r := range word {
+    if !isWordRune(r) {
+      return fmt.Errorf("%q can never match, words may only contain letters and digits", word)
+    }
+  }
+  return nil
+}
+
+func (l *WordList) Filter(body string) Result {
+  res := Result{Matches: []string{}}
+  var out strings.Builder
+  start := -1
+  flush := func(end int) {
+    word := body[start:end]
+    action, ok := l.words[strings.ToLower(word)]
+    if !ok {
+      out.WriteString(word)
+      return
+    }
+    res.add(strings.ToLower(word), action)
+    if action == Mask {
+      out.WriteString(maskText)
+    } else {
+      out.WriteString(word)
+    }
+  }
+
+  for i, r := range body {
+    if isWordRune(r) {
+      if start < 0 {
+        start = i
+      }
+      continue
+    }
+    if start >= 0 {
+      flush(i)
+      start = -1
+    }
+    out.WriteRune(r)
+  }
+  if start >= 0 {
+    flush(len(body))
+  }
+
+  res.Body = out.String()
+  return res
+}
@@ -0,0 +1,61 @@
+package moderation
+
+import (
+  "slices"
+  "strings"
+  "testing"
+)
+
+func TestWordListMasks(t *testing.T) {
+  list := NewWordList(map[string]Action{"kerfuffle": Mask, "sharbert": Mask, "fornax": Mask})
+  cases := map[string]string{
+    "This is a kerfuffle opinion I need to share with the world": "This is a **** opinion I need to share with the world",
+    "I had something interesting for breakfast":                  "I had something interesting for breakfast",
+    "I hear Mastodon is better than Chirpy. sharbert I need to migrate": "I hear Mastodon is better than Chirpy. **** I need to migrate",
+    "KERFUFFLE! Sharbert? fOrNaX.":                               "****! ****? ****.",
+    // substrings of longer words are not matches
+    "kerfuffles and fornaxian sharberts": "kerfuffles and fornaxian sharberts",
+  }
+  for body, expected := range cases {
+    res := list.Filter(body)
+    if res.Body != expected {
+      t.Errorf("incorrect body for %q, expected %q, got %q", body, expected, res.Body)
+    }
+  }
+}
+
+func TestWordListScunthorpe(t *testing.T) {
+  list := NewWordList(map[string]Action{"cunt": Reject, "ass": Hold})
+  for _, body := range []string{"Visiting Scunthorpe", "a classic assessment", "Sussex"} {
+    if res := list.Filter(body); res.Action != Allow || len(res.Matches) != 0 {
+      t.Errorf("false positive on %q: %v", body, res)
+    }
+  }
+}
+
+func TestLoadWordList(t *testing.T) {
+  list, err := LoadWordList(strings.NewReader("# comment\n\nkerfuffle\nspam hold\nscam reject\n"))
+  if err != nil {
+    t.Fatalf("error loading list: %v", err)
+  }
+
+  res := list.Filter("kerfuffle spam")
+  if res.Body != "**** spam" || res.Action != Hold || !slices.Equal(res.Matches, []string{"kerfuffle", "spam"}) {
+    t.Errorf("incorrect result %+v", res)
+  }
+  if res = list.Filter("a scam"); res.Action != Reject {
+    t.Errorf("expected reject, got %v", res.Action)
+  }
+
+  if _, err = LoadWordList(strings.NewReader("word explode\n")); err == nil {
+    t.Errorf("expected an unknown action to fail")
+  }
+  if _, err = LoadWordList(strings.NewReader("two words mask\n")); err == nil {
+    t.Errorf("expected extra fields to fail")
+  }
+  for _, entry := range []string{"don't", "f*ck hold", "e-mail"} {
+    if _, err = LoadWordList(strings.NewReader(entry + "\n")); err == nil {
+      t.Errorf("expected %q to fail, it can never match", entry)
+    }
+  }
+}
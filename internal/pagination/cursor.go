@@ -0,0 +1,93 @@
+package pagination
+
+import (
+  "encoding/base64"
+  "errors"
+  "strconv"
+  "strings"
+  "time"
+
+  "github.com/google/uuid"
+)
+
+const (
+  DefaultLimit = 20
+  MaxLimit     = 100
+)
+
+// Cursor marks a position in a list ordered by (created_at, id).
+type Cursor struct {
+  CreatedAt time.Time
+  ID        uuid.UUID
+}
+
+func (c Cursor) Encode() string {
+  raw := strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + ":" + c.ID.String()
+  return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(s string) (Cursor, error) {
+  raw, err := base64.RawURLEncoding.DecodeString(s)
+  if err != nil {
+    return Cursor{}, errors.New("invalid cursor")
+  }
+
+  nanos, id, found := strings.Cut(string(raw), ":")
+  if !found {
+    return Cursor{}, errors.New("invalid cursor")
+  }
+
+  n, err := strconv.ParseInt(nanos, 10, 64)
+  if err != nil {
+    return Cursor{}, errors.New("invalid cursor")
+  }
+
+  cursorID, err := uuid.Parse(id)
+  if err != nil {
+    return Cursor{}, errors.New("invalid cursor")
+  }
+
+  return Cursor{time.Unix(0, n).UTC(), cursorID}, nil
+}
+
+// ParseLimit reads a page size from a query string value, falling back to
+// DefaultLimit when it is empty.
+func ParseLimit(s string) (int32, error) {
+  if s == "" {
+    return DefaultLimit, nil
+  }
+
+  limit, err := strconv.Atoi(s)
+  if err != nil || limit < 1 {
+    return 0, errors.New("limit must be a positive integer")
+  }
+  if limit > MaxLimit {
+    return MaxLimit, nil
+  }
+  return int32(limit), nil
+}
+
+// EncodeOffset builds a cursor for lists, like ranked search results, that
+// can only be paged by position.
+func EncodeOffset(offset int32) string {
+  return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(int(offset))))
+}
+
+func DecodeOffset(s string) (int32, error) {
+  raw, err := base64.RawURLEncoding.DecodeString(s)
+  if err != nil {
+    return 0, errors.New("invalid cursor")
+  }
+
+  n, found := strings.CutPrefix(string(raw), "offset:")
+  if !found {
+    return 0, errors.New("invalid cursor")
+  }
+
+  // anything past int32 would wrap negative on the way to OFFSET
+  offset, err := strconv.ParseInt(n, 10, 32)
+  if err != nil || offset < 0 {
+    return 0, errors.New("invalid cursor")
+  }
+  return int32(offset), nil
+}
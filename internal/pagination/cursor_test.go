@@ -0,0 +1,70 @@
+package pagination
+
+import (
+  "encoding/base64"
+  "testing"
+  "time"
+
+  "github.com/google/uuid"
+)
+
+func TestCursor(t *testing.T) {
+  cursor := Cursor{time.Now().UTC(), uuid.New()}
+
+  res, err := DecodeCursor(cursor.Encode())
+  if err != nil {
+    t.Errorf("error decoding cursor: %v", err)
+  }
+
+  if !res.CreatedAt.Equal(cursor.CreatedAt) || res.ID != cursor.ID {
+    t.Errorf("incorrect cursor, expected %v, got %v", cursor, res)
+  }
+
+  if _, err = DecodeCursor("not a cursor"); err == nil {
+    t.Errorf("expected invalid cursor to fail decoding")
+  }
+}
+
+func TestParseLimit(t *testing.T) {
+  cases := map[string]int32{
+    "":    DefaultLimit,
+    "5":   5,
+    "500": MaxLimit,
+  }
+  for s, expected := range cases {
+    limit, err := ParseLimit(s)
+    if err != nil {
+      t.Errorf("error parsing limit %q: %v", s, err)
+    }
+    if limit != expected {
+      t.Errorf("incorrect limit for %q, expected %d, got %d", s, expected, limit)
+    }
+  }
+
+  for _, s := range []string{"0", "-1", "ten"} {
+    if _, err := ParseLimit(s); err == nil {
+      t.Errorf("expected limit %q to be rejected", s)
+    }
+  }
+}
+
+func TestOffset(t *testing.T) {
+  res, err := DecodeOffset(EncodeOffset(40))
+  if err != nil {
+    t.Errorf("error decoding offset: %v", err)
+  }
+  if res != 40 {
+    t.Errorf("incorrect offset, expected 40, got %d", res)
+  }
+
+  if _, err = DecodeOffset(Cursor{}.Encode()); err == nil {
+    t.Errorf("expected a time cursor to fail decoding as an offset")
+  }
+
+  for _, n := range []string{"-1", "2147483648", "99999999999"} {
+    cursor := base64.RawURLEncoding.EncodeToString([]byte("offset:" + n))
+    if _, err = DecodeOffset(cursor); err == nil {
+      t.Errorf("expected offset %s to fail decoding", n)
+    }
+  }
+}
@@ -0,0 +1,97 @@
+package search
+
+import (
+  "errors"
+  "fmt"
+  "strings"
+  "time"
+  "unicode"
+)
+
+const dateLayout = "2006-01-02"
+
+// Query is a parsed search string. Text keeps the free-text part, including
+// any "quoted phrases", in a form meant for Postgres' websearch_to_tsquery,
+// so nothing from the user is ever spliced into SQL.
+type Query struct {
+  Text    string
+  From    string
+  Since   *time.Time
+  Until   *time.Time
+}
+
+// Parse splits the from:, since: and until: operators out of a search
+// string. Dates are YYYY-MM-DD; until: includes the whole of its day.
+func Parse(s string) (Query, error) {
+  query := Query{}
+  text := []string{}
+  for _, token := range tokenize(s) {
+    key, value, found := strings.Cut(token, ":")
+    if !found || strings.HasPrefix(token, `"`) {
+      text = append(text, token)
+      continue
+    }
+
+    switch strings.ToLower(key) {
+    case "from":
+      if value == "" || query.From != "" {
+        return Query{}, errors.New("from: needs exactly one username")
+      }
+      query.From = strings.TrimPrefix(value, "@")
+    case "since":
+      if query.Since != nil {
+        return Query{}, errors.New("since: can only be used once")
+      }
+      since, err := time.Parse(dateLayout, value)
+      if err != nil {
+        return Query{}, fmt.Errorf("since: must be a date like %s", dateLayout)
+      }
+      query.Since = &since
+    case "until":
+      if query.Until != nil {
+        return Query{}, errors.New("until: can only be used once")
+      }
+      until, err := time.Parse(dateLayout, value)
+      if err != nil {
+        return Query{}, fmt.Errorf("until: must be a date like %s", dateLayout)
+      }
+      until = until.AddDate(0, 0, 1)
+      query.Until = &until
+    default:
+      text = append(text, token)
+    }
+  }
+
+  if query.Since != nil && query.Until != nil && !query.Since.Before(*query.Until) {
+    return Query{}, errors.New("since: must be before until:")
+  }
+
+  query.Text = strings.Join(text, " ")
+  return query, nil
+}
+
+// tokenize splits s on whitespace, keeping "quoted phrases" together with
+// their quotes. An unterminated quote runs to the end of the string.
+func tokenize(s string) []string {
+  tokens := []string{}
+  current := strings.Builder{}
+  quoted := false
+  for _, r := range s {
+    switch {
+    case r == '"':
+      current.WriteRune(r)
+      quoted = !quoted
+    case unicode.IsSpace(r) && !quoted:
+      if current.Len() > 0 {
+        tokens = append(tokens, current.String())
+        current.Reset()
+      }
+    default:
+      current.WriteRune(r)
+    }
+  }
+  if current.Len() > 0 {
+    tokens = append(tokens, current.String())
+  }
+  return tokens
+}
@@ -0,0 +1,50 @@
+package search
+
+import (
+  "testing"
+  "time"
+)
+
+func TestParse(t *testing.T) {
+  query, err := Parse(`go "error handling" from:@Alice since:2024-01-01 until:2024-01-31 -java`)
+  if err != nil {
+    t.Errorf("error parsing query: %v", err)
+  }
+
+  if query.Text != `go "error handling" -java` {
+    t.Errorf("incorrect text, got %q", query.Text)
+  }
+  if query.From != "Alice" {
+    t.Errorf("incorrect from, expected Alice, got %q", query.From)
+  }
+  if query.Since == nil || !query.Since.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+    t.Errorf("incorrect since, got %v", query.Since)
+  }
+  if query.Until == nil || !query.Until.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) {
+    t.Errorf("incorrect until, expected the day after, got %v", query.Until)
+  }
+}
+
+func TestParseKeepsQuotedColons(t *testing.T) {
+  query, err := Parse(`"from:nobody" time: 10:30`)
+  if err != nil {
+    t.Errorf("error parsing query: %v", err)
+  }
+  if query.Text != `"from:nobody" time: 10:30` || query.From != "" {
+    t.Errorf("expected operators inside quotes and unknown keys to stay in the text, got %+v", query)
+  }
+}
+
+func TestParseErrors(t *testing.T) {
+  for _, s := range []string{
+    "since:yesterday",
+    "until:2024-13-01",
+    "from:",
+    "from:a from:b",
+    "since:2024-02-01 until:2024-01-01",
+  } {
+    if _, err := Parse(s); err == nil {
+      t.Errorf("expected %q to fail parsing", s)
+    }
+  }
+}
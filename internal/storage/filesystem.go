@@ -0,0 +1,68 @@
+package storage
+
+import (
+  "context"
+  "errors"
+  "io"
+  "io/fs"
+  "os"
+  "path/filepath"
+)
+
+// FileStore keeps blobs as files in a single directory.
+type FileStore struct {
+  dir string
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+  if err := os.MkdirAll(dir, 0o755); err != nil {
+    return nil, err
+  }
+  return &FileStore{dir}, nil
+}
+
+func (s *FileStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+  if !ValidKey(key) {
+    return ErrInvalidKey
+  }
+
+  // write to a temporary file first so readers never see half an upload
+  tmp, err := os.CreateTemp(s.dir, ".upload-*")
+  if err != nil {
+    return err
+  }
+  defer os.Remove(tmp.Name())
+
+  if _, err = io.Copy(tmp, body); err != nil {
+    tmp.Close()
+    return err
+  }
+  if err = tmp.Close(); err != nil {
+    return err
+  }
+  return os.Rename(tmp.Name(), filepath.Join(s.dir, key))
+}
+
+func (s *FileStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+  if !ValidKey(key) {
+    return nil, ErrInvalidKey
+  }
+
+  f, err := os.Open(filepath.Join(s.dir, key))
+  if errors.Is(err, fs.ErrNotExist) {
+    return nil, ErrNotFound
+  }
+  return f, err
+}
+
+func (s *FileStore) Delete(ctx context.Context, key string) error {
+  if !ValidKey(key) {
+    return ErrInvalidKey
+  }
+
+  err := os.Remove(filepath.Join(s.dir, key))
+  if errors.Is(err, fs.ErrNotExist) {
+    return nil
+  }
+  return err
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+  "context"
+  "errors"
+  "io"
+  "strings"
+  "testing"
+)
+
+func TestFileStore(t *testing.T) {
+  store, err := NewFileStore(t.TempDir())
+  if err != nil {
+    t.Fatalf("error creating store: %v", err)
+  }
+  testStore(t, store)
+
+  if err = store.Put(context.Background(), "../escape", strings.NewReader("x"), 1, "text/plain"); !errors.Is(err, ErrInvalidKey) {
+    t.Errorf("expected path traversal to be rejected, got %v", err)
+  }
+}
+
+// testStore exercises the behaviour every backend has to share.
+func testStore(t *testing.T, store Store) {
+  ctx := context.Background()
+  if err := store.Put(ctx, "hello.txt", strings.NewReader("hello"), 5, "text/plain"); err != nil {
+    t.Fatalf("error putting object: %v", err)
+  }
+
+  body, err := store.Get(ctx, "hello.txt")
+  if err != nil {
+    t.Fatalf("error getting object: %v", err)
+  }
+  data, _ := io.ReadAll(body)
+  body.Close()
+  if string(data) != "hello" {
+    t.Errorf("incorrect object, expected hello, got %q", data)
+  }
+
+  if err = store.Delete(ctx, "hello.txt"); err != nil {
+    t.Errorf("error deleting object: %v", err)
+  }
+  if _, err = store.Get(ctx, "hello.txt"); !errors.Is(err, ErrNotFound) {
+    t.Errorf("expected deleted object to be missing, got %v", err)
+  }
+}
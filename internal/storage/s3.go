@@ -0,0 +1,177 @@
+package storage
+
+import (
+  "bytes"
+  "context"
+  "crypto/hmac"
+  "crypto/sha256"
+  "encoding/hex"
+  "fmt"
+  "io"
+  "net/http"
+  "net/url"
+  "sort"
+  "strings"
+  "time"
+)
+
+// S3Store talks to any S3-compatible service (AWS, MinIO, ...) using
+// path-style URLs and Signature Version 4.
+type S3Store struct {
+  endpoint        *url.URL
+  bucket          string
+  region          string
+  accessKeyID     string
+  secretAccessKey string
+  client          *http.Client
+}
+
+func NewS3Store(endpoint, bucket, region, accessKeyID, secretAccessKey string) (*S3Store, error) {
+  u, err := url.Parse(endpoint)
+  if err != nil {
+    return nil, err
+  }
+  if u.Scheme == "" || u.Host == "" {
+    return nil, fmt.Errorf("invalid S3 endpoint %q", endpoint)
+  }
+  if bucket == "" {
+    return nil, fmt.Errorf("S3 bucket is required")
+  }
+  return &S3Store{u, bucket, region, accessKeyID, secretAccessKey, &http.Client{Timeout: time.Minute}}, nil
+}
+
+func (s *S3Store) objectURL(key string) string {
+  return strings.TrimSuffix(s.endpoint.String(), "/") + "/" + s.bucket + "/" + key
+}
+
+func (s *S3Store) do(ctx context.Context, method, key string, body []byte, header http.Header) (*http.Response, error) {
+  req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key), bytes.NewReader(body))
+  if err != nil {
+    return nil, err
+  }
+  for name, values := range header {
+    req.Header[name] = values
+  }
+  req.ContentLength = int64(len(body))
+
+  payloadHash := sha256.Sum256(body)
+  signV4(req, hex.EncodeToString(payloadHash[:]), s.accessKeyID, s.secretAccessKey, s.region, time.Now())
+  return s.client.Do(req)
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+  if !ValidKey(key) {
+    return ErrInvalidKey
+  }
+
+  // the payload has to be hashed for the signature, and uploads are capped
+  // well below anything that would be a problem to hold in memory
+  data, err := io.ReadAll(body)
+  if err != nil {
+    return err
+  }
+
+  header := http.Header{}
+  header.Set("Content-Type", contentType)
+  res, err := s.do(ctx, http.MethodPut, key, data, header)
+  if err != nil {
+    return err
+  }
+  defer res.Body.Close()
+
+  if res.StatusCode != http.StatusOK {
+    return fmt.Errorf("S3 put failed: %s", res.Status)
+  }
+  return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+  if !ValidKey(key) {
+    return nil, ErrInvalidKey
+  }
+
+  res, err := s.do(ctx, http.MethodGet, key, nil, nil)
+  if err != nil {
+    return nil, err
+  }
+
+  switch res.StatusCode {
+  case http.StatusOK:
+    return res.Body, nil
+  case http.StatusNotFound:
+    res.Body.Close()
+    return nil, ErrNotFound
+  default:
+    res.Body.Close()
+    return nil, fmt.Errorf("S3 get failed: %s", res.Status)
+  }
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+  if !ValidKey(key) {
+    return ErrInvalidKey
+  }
+
+  res, err := s.do(ctx, http.MethodDelete, key, nil, nil)
+  if err != nil {
+    return err
+  }
+  defer res.Body.Close()
+
+  if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotFound {
+    return fmt.Errorf("S3 delete failed: %s", res.Status)
+  }
+  return nil
+}
+
+// signV4 adds an AWS Signature Version 4 Authorization header to req,
+// signing the host and every header already set on it.
+func signV4(req *http.Request, payloadHash, accessKeyID, secretAccessKey, region string, now time.Time) {
+  amzDate := now.UTC().Format("20060102T150405Z")
+  date := amzDate[:8]
+  req.Header.Set("X-Amz-Date", amzDate)
+  req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+  headers := map[string]string{"host": req.URL.Host}
+  for name, values := range req.Header {
+    headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+  }
+  names := make([]string, 0, len(headers))
+  for name := range headers {
+    names = append(names, name)
+  }
+  sort.Strings(names)
+
+  canonicalHeaders := strings.Builder{}
+  for _, name := range names {
+    canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+  }
+  signedHeaders := strings.Join(names, ";")
+
+  canonicalRequest := strings.Join([]string{
+    req.Method,
+    req.URL.EscapedPath(),
+    req.URL.Query().Encode(),
+    canonicalHeaders.String(),
+    signedHeaders,
+    payloadHash,
+  }, "\n")
+
+  scope := date + "/" + region + "/s3/aws4_request"
+  requestHash := sha256.Sum256([]byte(canonicalRequest))
+  stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+  key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+  key = hmacSHA256(key, region)
+  key = hmacSHA256(key, "s3")
+  key = hmacSHA256(key, "aws4_request")
+  signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+  req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKeyID+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+  h := hmac.New(sha256.New, key)
+  h.Write([]byte(data))
+  return h.Sum(nil)
+}
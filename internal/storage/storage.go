@@ -0,0 +1,28 @@
+package storage
+
+import (
+  "context"
+  "errors"
+  "io"
+  "regexp"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+var ErrInvalidKey = errors.New("invalid object key")
+
+// Store holds uploaded blobs under flat keys.
+type Store interface {
+  Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+  // Get returns ErrNotFound when nothing is stored under key.
+  Get(ctx context.Context, key string) (io.ReadCloser, error)
+  Delete(ctx context.Context, key string) error
+}
+
+var keyRegexp = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*$`)
+
+// ValidKey reports whether key is safe to use with every backend, which
+// rules out path separators and names like "..".
+func ValidKey(key string) bool {
+  return len(key) <= 255 && keyRegexp.MatchString(key)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+  "context"
+  "encoding/json"
+  "fmt"
+  "net/http"
+  "os"
+  "time"
+
+  "github.com/j-wut/chirpy/internal/auth"
+  "github.com/j-wut/chirpy/internal/database"
+)
+
+const (
+  defaultKeyRotationInterval = 30 * 24 * time.Hour
+  keyRefreshInterval         = time.Minute
+
+  // a new key is published this long before it starts signing, so
+  // verifiers caching the JWKS have time to pick it up
+  keyPublishDelay = 15 * time.Minute
+
+  // login and refresh never issue access tokens that live longer
+  maxAccessTokenLifetime = time.Hour
+
+  defaultJWTLeeway  = 30 * time.Second
+  maxJWTLeeway      = 5 * time.Minute
+
+  // a retired key keeps verifying until every token it signed has expired
+  keyRetention = keyPublishDelay + maxAccessTokenLifetime + maxJWTLeeway + keyRefreshInterval
+)
+
+type keyConfig struct {
+  algorithm         string
+  rotationInterval  time.Duration
+  // private keys are only stored encrypted with this
+  encryptionKey     []byte
+  leeway            time.Duration
+}
+
+// signingKeyConfig reads JWT_ALGORITHM (EdDSA or RS256),
+// JWT_ROTATION_INTERVAL (a Go duration such as 720h), JWT_LEEWAY, the clock
+// skew allowed when checking token times, and the required
+// JWT_KEY_ENCRYPTION_KEY (32 random bytes, base64 encoded).
+func signingKeyConfig() (keyConfig, error) {
+  conf := keyConfig{algorithm: auth.AlgEdDSA, rotationInterval: defaultKeyRotationInterval, leeway: defaultJWTLeeway}
+  kek, err := auth.ParseKeyEncryptionKey(os.Getenv("JWT_KEY_ENCRYPTION_KEY"))
+  if err != nil {
+    return keyConfig{}, fmt.Errorf("JWT_KEY_ENCRYPTION_KEY: %w", err)
+  }
+  conf.encryptionKey = kek
+  if alg := os.Getenv("JWT_ALGORITHM"); alg != "" {
+    if !auth.ValidAlgorithm(alg) {
+      return keyConfig{}, fmt.Errorf("JWT_ALGORITHM must be %s or %s", auth.AlgEdDSA, auth.AlgRS256)
+    }
+    conf.algorithm = alg
+  }
+  if interval := os.Getenv("JWT_ROTATION_INTERVAL"); interval != "" {
+    parsed, err := time.ParseDuration(interval)
+    if err != nil || parsed <= keyRetention {
+      return keyConfig{}, fmt.Errorf("JWT_ROTATION_INTERVAL must be a duration longer than %s", keyRetention)
+    }
+    conf.rotationInterval = parsed
+  }
+  if leeway := os.Getenv("JWT_LEEWAY"); leeway != "" {
+    parsed, err := time.ParseDuration(leeway)
+    if err != nil || parsed < 0 || parsed > maxJWTLeeway {
+      return keyConfig{}, fmt.Errorf("JWT_LEEWAY must be a duration between 0 and %s", maxJWTLeeway)
+    }
+    conf.leeway = parsed
+  }
+  return conf, nil
+}
+
+// loadSigningKeys reads every unexpired key from the database and decrypts
+// it with kek.
+func loadSigningKeys(ctx context.Context, q *database.Queries, kek []byte) ([]*auth.SigningKey, error) {
+  rows, err := q.GetSigningKeys(ctx)
+  if err != nil {
+    return nil, err
+  }
+
+  keys := []*auth.SigningKey{}
+  for _, row := range rows {
+    key, err := auth.OpenSigningKey(row.Kid, row.Algorithm, row.EncryptedPrivateKey, kek)
+    if err != nil {
+      return nil, err
+    }
+    key.ActiveAt = row.ActiveAt
+    if row.ExpiresAt.Valid {
+      key.ExpiresAt = row.ExpiresAt.Time
+    }
+    keys = append(keys, key)
+  }
+  return keys, nil
+}
+
+// rotateSigningKeys adds a new key once the newest one for the configured
+// algorithm is older than the rotation interval, and retires the rest. Every
+// instance runs it, taking turns on a lock so only the first one that's due
+// inserts a key.
+func (cfg *apiConfig) rotateSigningKeys(ctx context.Context, conf keyConfig) error {
+  now := time.Now()
+  key, err := auth.GenerateSigningKey(conf.algorithm)
+  if err != nil {
+    return err
+  }
+  sealed, err := key.SealPrivateKey(conf.encryptionKey)
+  if err != nil {
+    return err
+  }
+
+  // with nothing to sign with yet there's no point waiting to publish
+  activeAt := now.Add(keyPublishDelay)
+  if _, err = cfg.keys.SigningKey(now); err != nil {
+    activeAt = now
+  }
+
+  tx, err := cfg.db.BeginTx(ctx, nil)
+  if err != nil {
+    return err
+  }
+  defer tx.Rollback()
+  qtx := cfg.dbQueries.WithTx(tx)
+
+  // without the lock two instances can both find no recent key and insert
+  if err = qtx.LockSigningKeys(ctx); err != nil {
+    return err
+  }
+
+  created, err := qtx.CreateSigningKeyIfDue(ctx, database.CreateSigningKeyIfDueParams{
+    Kid: key.ID,
+    Algorithm: key.Algorithm,
+    EncryptedPrivateKey: sealed,
+    ActiveAt: activeAt,
+    DueAfter: now.Add(-conf.rotationInterval),
+  })
+  if err != nil || created == 0 {
+    return err
+  }
+  if err = qtx.RetireSigningKeys(ctx, database.RetireSigningKeysParams{ExpiresAt: activeAt.Add(keyRetention), Kid: key.ID}); err != nil {
+    return err
+  }
+  if err = qtx.DeleteExpiredSigningKeys(ctx); err != nil {
+    return err
+  }
+  return tx.Commit()
+}
+
+// refreshSigningKeys rotates if due and reloads the keyring, so keys made by
+// other instances are picked up too.
+func (cfg *apiConfig) refreshSigningKeys(ctx context.Context, conf keyConfig) error {
+  if err := cfg.rotateSigningKeys(ctx, conf); err != nil {
+    return err
+  }
+  keys, err := loadSigningKeys(ctx, cfg.dbQueries, conf.encryptionKey)
+  if err != nil {
+    return err
+  }
+  cfg.keys.Replace(keys)
+  return nil
+}
+
+// watchSigningKeys keeps the keyring current until ctx is done.
+func (cfg *apiConfig) watchSigningKeys(ctx context.Context, conf keyConfig) {
+  ticker := time.NewTicker(keyRefreshInterval)
+  defer ticker.Stop()
+  for {
+    select {
+    case <-ctx.Done():
+      return
+    case <-ticker.C:
+      if err := cfg.refreshSigningKeys(ctx, conf); err != nil {
+        fmt.Printf("Error refreshing signing keys: %s", err)
+      }
+    }
+  }
+}
+
+func (cfg *apiConfig) getJWKS(w http.ResponseWriter, r *http.Request) {
+  resStr, err := json.Marshal(cfg.keys.JWKS(time.Now()))
+  if err != nil {
+    fmt.Printf("Error Marshalling JWKS: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(keyRefreshInterval.Seconds())))
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
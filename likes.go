@@ -0,0 +1,107 @@
+package main
+
+import (
+  "context"
+  "database/sql"
+  "errors"
+  "fmt"
+  "net/http"
+
+  "github.com/google/uuid"
+
+  "github.com/j-wut/chirpy/internal/auth"
+  "github.com/j-wut/chirpy/internal/database"
+)
+
+// addLikes fills in like counts, and whether viewer liked each chirp when
+// there is a viewer.
+func (cfg *apiConfig) addLikes(ctx context.Context, viewer uuid.NullUUID, chirps []ReadableChirp) error {
+  ids := liveChirpIDs(chirps)
+  if len(ids) == 0 {
+    return nil
+  }
+
+  counts, err := cfg.dbQueries.GetLikeCounts(ctx, ids)
+  if err != nil {
+    return err
+  }
+  countByID := map[uuid.UUID]int64{}
+  for _, count := range counts {
+    countByID[count.ChirpID] = count.LikeCount
+  }
+
+  likedByID := map[uuid.UUID]bool{}
+  if viewer.Valid {
+    liked, err := cfg.dbQueries.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{UserID: viewer.UUID, ChirpIds: ids})
+    if err != nil {
+      return err
+    }
+    for _, id := range liked {
+      likedByID[id] = true
+    }
+  }
+
+  for i := range chirps {
+    chirps[i].LikeCount = countByID[chirps[i].ID]
+    chirps[i].LikedByMe = likedByID[chirps[i].ID]
+  }
+  return nil
+}
+
+func (cfg *apiConfig) like(w http.ResponseWriter, r *http.Request) {
+  cfg.setLike(w, r, true)
+}
+
+func (cfg *apiConfig) unlike(w http.ResponseWriter, r *http.Request) {
+  cfg.setLike(w, r, false)
+}
+
+func (cfg *apiConfig) setLike(w http.ResponseWriter, r *http.Request, liked bool) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsWrite)
+  if !ok {
+    return
+  }
+
+  chirpID, err := uuid.Parse(r.PathValue("id"))
+  if err != nil {
+    w.WriteHeader(404)
+    return
+  }
+
+  chirp, err := cfg.dbQueries.GetChirp(r.Context(), chirpID)
+  if errors.Is(err, sql.ErrNoRows) || chirp.DeletedAt.Valid {
+    w.WriteHeader(404)
+    return
+  } else if err != nil {
+    fmt.Printf("Error retrieving chirp: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  if liked {
+    visible, err := cfg.authorVisibleTo(r.Context(), chirp.UserID, uuid.NullUUID{UUID: userID, Valid: true})
+    if err != nil {
+      fmt.Printf("Error checking author: %s", err)
+      w.WriteHeader(500)
+      return
+    }
+    if !visible {
+      w.WriteHeader(404)
+      return
+    }
+  }
+
+  if liked {
+    err = cfg.dbQueries.CreateLike(r.Context(), database.CreateLikeParams{UserID: userID, ChirpID: chirp.ID})
+  } else {
+    err = cfg.dbQueries.DeleteLike(r.Context(), database.DeleteLikeParams{UserID: userID, ChirpID: chirp.ID})
+  }
+  if err != nil {
+    fmt.Printf("Error saving like: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.WriteHeader(204)
+  return
+}
@@ -1,30 +1,37 @@
 package main
 
-import _ "github.com/lib/pq"
-
 import (
 	"io"
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"sync/atomic"
 	"encoding/json"
-	"regexp"
 	"os"
 	"database/sql"
 	"time"
 	"strings"
+	"slices"
 
 	"github.com/joho/godotenv"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 
 	"github.com/j-wut/chirpy/internal/database"
   "github.com/j-wut/chirpy/internal/auth"
+  "github.com/j-wut/chirpy/internal/entities"
+  "github.com/j-wut/chirpy/internal/moderation"
+  "github.com/j-wut/chirpy/internal/pagination"
+  "github.com/j-wut/chirpy/internal/storage"
 )
 
 type UserRequest struct {
 	Email             string  `json:"email"`
   Password          string  `json:"password"`
+  Username          string  `json:"username"`
   ExpiresInSeconds  uint    `json:"expires_in_seconds"`
+  SessionName       string  `json:"session_name"`
 }
 
 type ReadableUser struct {
@@ -32,6 +39,12 @@ type ReadableUser struct {
   CreatedAt     time.Time `json:"created_at"`
   UpdatedAt     time.Time `json:"updated_at"`
   Email         string    `json:"email"`
+  Username      string    `json:"username"`
+  DisplayName   string    `json:"display_name"`
+  Bio           string    `json:"bio"`
+  AvatarURL     string    `json:"avatar_url"`
+  Role          string    `json:"role"`
+  Protected     bool      `json:"protected"`
   Token         string    `json:"token,omitempty"` 
   RefreshToken  string    `json:"refresh_token,omitempty"`
 }
@@ -42,15 +55,86 @@ func DatabaseUserToReadable(user database.User) ReadableUser {
     user.CreatedAt,
     user.UpdatedAt,
     user.Email,
+    user.Username,
+    user.DisplayName,
+    user.Bio,
+    user.AvatarUrl,
+    user.Role,
+    user.Protected,
     "",
     "",
   }
 }
 
+type ChirpRequest struct {
+  Body        string        `json:"body"`
+  InReplyTo   uuid.NullUUID `json:"in_reply_to"`
+  RepostOf    uuid.NullUUID `json:"repost_of"`
+  MediaIDs    []uuid.UUID   `json:"media_ids"`
+}
+
+type ReadableChirp struct {
+  ID              uuid.UUID     `json:"id"`
+  CreatedAt       time.Time     `json:"created_at"`
+  UpdatedAt       time.Time     `json:"updated_at"`
+  Body            string        `json:"body"`
+  UserID          uuid.UUID     `json:"user_id"`
+  InReplyTo       uuid.NullUUID `json:"in_reply_to"`
+  ConversationID  uuid.UUID     `json:"conversation_id"`
+  Deleted         bool          `json:"deleted,omitempty"`
+  Kind            string          `json:"kind"`
+  RepostOf        uuid.NullUUID   `json:"repost_of"`
+  Original        *ReadableChirp  `json:"original,omitempty"`
+  OriginalDeleted bool            `json:"original_deleted,omitempty"`
+  Entities        []Entity        `json:"entities"`
+  LikeCount       int64           `json:"like_count"`
+  LikedByMe       bool            `json:"liked_by_me"`
+  Held            bool            `json:"held,omitempty"`
+  Media           []ReadableMedia `json:"media"`
+}
+
+func DatabaseChirpToReadable(chirp database.Chirp) ReadableChirp {
+  readable := ReadableChirp{
+    ID: chirp.ID,
+    CreatedAt: chirp.CreatedAt,
+    UpdatedAt: chirp.UpdatedAt,
+    Body: chirp.Body,
+    UserID: chirp.UserID,
+    InReplyTo: chirp.InReplyTo,
+    ConversationID: chirp.ConversationID,
+    Deleted: chirp.DeletedAt.Valid,
+    Kind: chirp.Kind,
+    RepostOf: chirp.RepostOf,
+    OriginalDeleted: chirp.Kind != chirpKindChirp && !chirp.RepostOf.Valid,
+    Entities: []Entity{},
+    Media: []ReadableMedia{},
+    Held: chirp.ModerationState == chirpHeld,
+  }
+  if readable.Deleted {
+    // tombstones only keep enough to hold their place in a thread
+    readable.UserID = uuid.Nil
+    readable.RepostOf = uuid.NullUUID{}
+    readable.Held = false
+  }
+  return readable
+}
+
+func DatabaseChirpsToReadable(chirps []database.Chirp) []ReadableChirp {
+  readable := []ReadableChirp{}
+  for _, chirp := range chirps {
+    readable = append(readable, DatabaseChirpToReadable(chirp))
+  }
+  return readable
+}
+
 type apiConfig struct {
 	fileserverHits atomic.Int32
+	db *sql.DB
 	dbQueries *database.Queries
-  jwtSecret string
+  keys *auth.Keyring
+  jwtLeeway time.Duration
+  media storage.Store
+  moderation moderation.Filter
 }
 
 func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
@@ -60,6 +144,35 @@ func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
 	})
 }
 
+// optionalUserID identifies the caller on endpoints that anonymous readers
+// can also use. A missing or invalid token is treated as anonymous, as is an
+// API key without the chirps:read scope.
+func (cfg *apiConfig) optionalUserID(r *http.Request) uuid.NullUUID {
+  bearer, err := auth.GetBearerToken(r.Header)
+  if err != nil {
+    return uuid.NullUUID{}
+  }
+
+  if auth.IsAPIKey(bearer) {
+    key, err := cfg.lookupAPIKey(r.Context(), bearer)
+    if err != nil || !auth.HasScope(key.Scopes, auth.ScopeChirpsRead) {
+      return uuid.NullUUID{}
+    }
+    return uuid.NullUUID{UUID: key.UserID, Valid: true}
+  }
+
+  userID, err := auth.ValidateJWT(bearer, cfg.keys, cfg.jwtLeeway)
+  if err != nil {
+    return uuid.NullUUID{}
+  }
+  return uuid.NullUUID{UUID: userID, Valid: true}
+}
+
+func isUniqueViolation(err error) bool {
+  var pqErr *pq.Error
+  return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
 func (cfg *apiConfig) hitsHandler(w http.ResponseWriter, request *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
 	w.WriteHeader(200)
@@ -123,8 +236,18 @@ func (cfg *apiConfig) createUser(w http.ResponseWriter, r *http.Request) {
     return
   }
 
-	user, err := cfg.dbQueries.CreateUser(r.Context(), database.CreateUserParams{requestBody.Email, hashedPass})
-	if err != nil {
+  if !entities.ValidUsername(requestBody.Username) {
+    w.WriteHeader(400)
+    w.Write([]byte("Usernames must be 3-30 letters, numbers or underscores"))
+    return
+  }
+
+	user, err := cfg.dbQueries.CreateUser(r.Context(), database.CreateUserParams{Email: requestBody.Email, HashedPassword: hashedPass, Username: requestBody.Username})
+	if isUniqueViolation(err) {
+		w.WriteHeader(409)
+		w.Write([]byte("Email or username is already taken"))
+		return
+	} else if err != nil {
 		fmt.Printf("Error creating user: %s", err)
 		w.WriteHeader(500)
 		return
@@ -169,6 +292,11 @@ func (cfg *apiConfig) login(w http.ResponseWriter, r *http.Request) {
     return
   }
   
+  if userSuspended(user) {
+    writeSuspended(w, user)
+    return
+  }
+
   readableUser := DatabaseUserToReadable(user)
 
   var tokenDuration time.Duration
@@ -178,7 +306,7 @@ func (cfg *apiConfig) login(w http.ResponseWriter, r *http.Request) {
     tokenDuration = time.Second * time.Duration(requestBody.ExpiresInSeconds)
   }
 
-  jwtToken, err := auth.MakeJWT(user.ID, cfg.jwtSecret, tokenDuration)
+  jwtToken, err := auth.MakeJWT(user.ID, user.Role, cfg.keys, tokenDuration)
   if err != nil {
     fmt.Printf("Error generating JWT: %s", err)
     w.WriteHeader(500)
@@ -187,14 +315,7 @@ func (cfg *apiConfig) login(w http.ResponseWriter, r *http.Request) {
 
   readableUser.Token = jwtToken
 
-  refreshToken, err := auth.MakeRefreshToken()
-  if err != nil {
-    fmt.Printf("Error generating refresh token: %s", err)
-    w.WriteHeader(500)
-    return
-  }
-
-  _, err = cfg.dbQueries.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{refreshToken, time.Now().AddDate(0,0,60), readableUser.ID})
+  refreshToken, err := issueRefreshToken(r, cfg.dbQueries, user.ID, uuid.New(), requestBody.SessionName)
   if err != nil {
     fmt.Printf("Error saving refresh token: %s", err)
     w.WriteHeader(500)
@@ -231,7 +352,53 @@ func (cfg *apiConfig) refresh(w http.ResponseWriter, r *http.Request) {
     return
   }
 
-  jwtToken, err := auth.MakeJWT(refreshToken.UserID, cfg.jwtSecret, time.Hour)
+  if refreshToken.RevokedAt.Valid {
+    if refreshToken.ReplacedBy.Valid {
+      if err = cfg.refreshTokenReused(r, refreshToken); err != nil {
+        fmt.Printf("Error revoking reused refresh token: %s", err)
+        w.WriteHeader(500)
+        return
+      }
+    }
+    fmt.Println("Revoked refresh token")
+    w.WriteHeader(401)
+    return
+  }
+  if time.Now().After(refreshToken.ExpiresAt) {
+    fmt.Println("Expired refresh token")
+    w.WriteHeader(401)
+    return
+  }
+
+  // look the role up again so a role change applies from the next refresh
+  user, err := cfg.dbQueries.GetUserByID(r.Context(), refreshToken.UserID)
+  if err != nil {
+    fmt.Printf("Error retrieving user: %s", err)
+    w.WriteHeader(401)
+    return
+  }
+  if userSuspended(user) {
+    writeSuspended(w, user)
+    return
+  }
+
+  // every refresh token is good for one use
+  newRefreshToken, err := cfg.rotateRefreshToken(r, refreshToken)
+  if errors.Is(err, errRefreshTokenReused) {
+    if err = cfg.refreshTokenReused(r, refreshToken); err != nil {
+      fmt.Printf("Error revoking reused refresh token: %s", err)
+      w.WriteHeader(500)
+      return
+    }
+    w.WriteHeader(401)
+    return
+  } else if err != nil {
+    fmt.Printf("Error rotating refresh token: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  jwtToken, err := auth.MakeJWT(user.ID, user.Role, cfg.keys, time.Hour)
   if err != nil {
     fmt.Printf("Error generating JWT: %s", err)
     w.WriteHeader(500)
@@ -239,9 +406,10 @@ func (cfg *apiConfig) refresh(w http.ResponseWriter, r *http.Request) {
   }
   
   type TokenResponse struct {
-    Token string `json:"token"`
+    Token         string `json:"token"`
+    RefreshToken  string `json:"refresh_token"`
   }
-  res := TokenResponse{jwtToken}
+  res := TokenResponse{jwtToken, newRefreshToken}
   resStr, err := json.Marshal(res)
 	if err != nil {
 		fmt.Printf("Error Marshalling Token: %s", err)
@@ -276,15 +444,8 @@ func (cfg *apiConfig) revoke(w http.ResponseWriter, r *http.Request) {
 }
 
 func (cfg *apiConfig) changePassword(w http.ResponseWriter, r *http.Request) {
-  bearer, err := auth.GetBearerToken(r.Header)
-  if err != nil {
-    w.WriteHeader(401)
-    return
-  }
-
-  userID, err := auth.ValidateJWT(bearer, cfg.jwtSecret)
-  if err != nil {
-    w.WriteHeader(401)
+  userID, ok := cfg.authenticate(w, r, auth.ScopeAccount)
+  if !ok {
     return
   }
 
@@ -304,57 +465,88 @@ func (cfg *apiConfig) changePassword(w http.ResponseWriter, r *http.Request) {
     return
   }
 
-  err = cfg.dbQueries.ChangeEmailPassword(r.Context(), database.ChangeEmailPasswordParams{userID, requestBody.Email, hashedPass})
-	if err != nil {
-		fmt.Printf("Error updating password: %s", err)
-		w.WriteHeader(500)
-		return
-	}
-
-  user, err := cfg.dbQueries.GetUser(r.Context(), requestBody.Email)
-	if err != nil {
-		fmt.Printf("Error retrieving user: %s", err)
-		w.WriteHeader(401)
-		return
-	}
-
-  readableUser := DatabaseUserToReadable(user)
+  if requestBody.Username != "" && !entities.ValidUsername(requestBody.Username) {
+    w.WriteHeader(400)
+    w.Write([]byte("Usernames must be 3-30 letters, numbers or underscores"))
+    return
+  }
 
-  var tokenDuration time.Duration
-  if requestBody.ExpiresInSeconds == 0 || time.Second * time.Duration(requestBody.ExpiresInSeconds) > time.Hour {
-    tokenDuration = time.Hour
-  } else {
-    tokenDuration = time.Second * time.Duration(requestBody.ExpiresInSeconds)
+  tx, err := cfg.db.BeginTx(r.Context(), nil)
+  if err != nil {
+    fmt.Printf("Error starting transaction: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  defer tx.Rollback()
+  qtx := cfg.dbQueries.WithTx(tx)
+
+  // the username is the only change that can clash, so it goes first and
+  // nothing is saved if it's taken
+  if requestBody.Username != "" {
+    err = qtx.UpdateUsername(r.Context(), database.UpdateUsernameParams{ID: userID, Username: requestBody.Username})
+    if isUniqueViolation(err) {
+      w.WriteHeader(409)
+      w.Write([]byte("Username is already taken"))
+      return
+    } else if err != nil {
+      fmt.Printf("Error updating username: %s", err)
+      w.WriteHeader(500)
+      return
+    }
   }
 
-  jwtToken, err := auth.MakeJWT(user.ID, cfg.jwtSecret, tokenDuration)
+  err = qtx.ChangeEmailPassword(r.Context(), database.ChangeEmailPasswordParams{ID: userID, Email: requestBody.Email, HashedPassword: hashedPass})
   if err != nil {
-    fmt.Printf("Error generating JWT: %s", err)
+    fmt.Printf("Error updating password: %s", err)
     w.WriteHeader(500)
     return
   }
 
-  readableUser.Token = jwtToken
+  user, err := qtx.GetUser(r.Context(), requestBody.Email)
+  if err != nil {
+    fmt.Printf("Error retrieving user: %s", err)
+    w.WriteHeader(401)
+    return
+  }
 
-  oldRefresh, _ := cfg.dbQueries.GetRefreshTokenFromUserID(r.Context(), userID)
-  if err = cfg.dbQueries.RevokeRefreshToken(r.Context(), oldRefresh.Token); err != nil {
-    fmt.Println("error revoking old refresh Token")
+  // a new password logs out every other device; the caller carries on with
+  // the fresh session issued here
+  if err = qtx.RevokeUserRefreshTokens(r.Context(), userID); err != nil {
+    fmt.Printf("Error revoking sessions: %s", err)
+    w.WriteHeader(500)
+    return
   }
 
-  refreshToken, err := auth.MakeRefreshToken()
+  refreshToken, err := issueRefreshToken(r, qtx, user.ID, uuid.New(), requestBody.SessionName)
   if err != nil {
-    fmt.Printf("Error generating refresh token: %s", err)
+    fmt.Printf("Error saving refresh token: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  if err = tx.Commit(); err != nil {
+    fmt.Printf("Error committing account change: %s", err)
     w.WriteHeader(500)
     return
   }
 
-  _, err = cfg.dbQueries.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{refreshToken, time.Now().AddDate(0,0,60), readableUser.ID})
+  readableUser := DatabaseUserToReadable(user)
+
+  var tokenDuration time.Duration
+  if requestBody.ExpiresInSeconds == 0 || time.Second * time.Duration(requestBody.ExpiresInSeconds) > time.Hour {
+    tokenDuration = time.Hour
+  } else {
+    tokenDuration = time.Second * time.Duration(requestBody.ExpiresInSeconds)
+  }
+
+  jwtToken, err := auth.MakeJWT(user.ID, user.Role, cfg.keys, tokenDuration)
   if err != nil {
-    fmt.Printf("Error saving refresh token: %s", err)
+    fmt.Printf("Error generating JWT: %s", err)
     w.WriteHeader(500)
     return
   }
 
+  readableUser.Token = jwtToken
   readableUser.RefreshToken = refreshToken
 
   resStr, err := json.Marshal(readableUser)
@@ -371,81 +563,350 @@ func (cfg *apiConfig) changePassword(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func (cfg *apiConfig) createChirp(w http.ResponseWriter, r *http.Request) {
-  bearer, err := auth.GetBearerToken(r.Header)
-  if err != nil {
-    w.WriteHeader(401)
-    return
-  }
+var errChirpTooLong = errors.New("Chirp is too long")
 
-  userID, err := auth.ValidateJWT(bearer, cfg.jwtSecret)
-  if err != nil {
-    w.WriteHeader(401)
+func (cfg *apiConfig) createChirp(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsWrite)
+  if !ok {
     return
   }
 
   decoder := json.NewDecoder(r.Body)
-  params := database.CreateChirpParams{
-    UserID: userID,
-  }
+  requestBody := ChirpRequest{}
 
-	if err = decoder.Decode(&params); err != nil {
+	if err := decoder.Decode(&requestBody); err != nil {
 		fmt.Printf("Error decoding parameters: %s", err)
 		w.WriteHeader(500)
 		return
 	}
 
-	if len(params.Body) > 140 {
+	if requestBody.InReplyTo.Valid && requestBody.RepostOf.Valid {
 		w.WriteHeader(400)
-		w.Write([]byte("Chirp is too long"))
+		w.Write([]byte("A chirp cannot be both a reply and a repost"))
 		return
 	}
-	profane := []string{"kerfuffle", "sharbert", "fornax"}
-	for _, s := range(profane) {
-		re := regexp.MustCompile(`(?i)`+s)
-		params.Body = re.ReplaceAllString(params.Body, "****")
+
+	cleaned, err := cfg.cleanChirpBody(requestBody.Body)
+	if errors.Is(err, errChirpRejected) {
+		if err := logAutoModeration(r.Context(), cfg.dbQueries, moderationActionAutoReject, userID, uuid.NullUUID{}, cleaned.Matches); err != nil {
+			fmt.Printf("Error logging rejected chirp: %s", err)
+			w.WriteHeader(500)
+			return
+		}
 	}
-	
+	if err != nil {
+		w.WriteHeader(400)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	body, state := cleaned.Text, cleaned.State
 
-	chirp, err := cfg.dbQueries.CreateChirp(r.Context(), params) 
+	if blocked, err := cfg.mentionsBlocked(r.Context(), userID, body); err != nil {
+		fmt.Printf("Error checking blocks: %s", err)
+		w.WriteHeader(500)
+		return
+	} else if blocked {
+		w.WriteHeader(403)
+		w.Write([]byte(errBlocked.Error()))
+		return
+	}
+
+	if len(requestBody.MediaIDs) > 0 && requestBody.RepostOf.Valid && body == "" {
+		w.WriteHeader(400)
+		w.Write([]byte("A rechirp cannot carry media"))
+		return
+	}
+	if err = cfg.checkMedia(r.Context(), userID, requestBody.MediaIDs); err != nil {
+		w.WriteHeader(400)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	if requestBody.InReplyTo.Valid && !requestBody.RepostOf.Valid {
+		parent, err := cfg.dbQueries.GetChirp(r.Context(), requestBody.InReplyTo.UUID)
+		if err == nil {
+			if blocked, err := cfg.blockedEitherWay(r.Context(), userID, parent.UserID); err != nil {
+				fmt.Printf("Error checking blocks: %s", err)
+				w.WriteHeader(500)
+				return
+			} else if blocked {
+				w.WriteHeader(403)
+				w.Write([]byte(errBlocked.Error()))
+				return
+			}
+			if visible, err := cfg.authorVisibleTo(r.Context(), parent.UserID, uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+				fmt.Printf("Error checking author: %s", err)
+				w.WriteHeader(500)
+				return
+			} else if !visible {
+				w.WriteHeader(400)
+				w.Write([]byte("Chirp being replied to does not exist"))
+				return
+			}
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			fmt.Printf("Error retrieving chirp: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+	}
+
+	// the chirp and everything indexed or queued for it land together, so a
+	// failure never leaves a saved chirp behind for the client to post again
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		fmt.Printf("Error starting transaction: %s", err)
+		w.WriteHeader(500)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	var chirp database.Chirp
+	if requestBody.RepostOf.Valid {
+		chirp, err = cfg.createRepost(r.Context(), qtx, userID, requestBody.RepostOf.UUID, body, state)
+		if errors.Is(err, sql.ErrNoRows) {
+			w.WriteHeader(400)
+			w.Write([]byte("Chirp being reposted does not exist"))
+			return
+		} else if errors.Is(err, errAlreadyRechirped) {
+			w.WriteHeader(409)
+			w.Write([]byte(err.Error()))
+			return
+		} else if errors.Is(err, errBlocked) {
+			w.WriteHeader(403)
+			w.Write([]byte(err.Error()))
+			return
+		}
+	} else if requestBody.InReplyTo.Valid {
+		chirp, err = qtx.CreateReply(r.Context(), database.CreateReplyParams{Body: body, UserID: userID, ModerationState: state, InReplyTo: requestBody.InReplyTo.UUID})
+		if errors.Is(err, sql.ErrNoRows) {
+			w.WriteHeader(400)
+			w.Write([]byte("Chirp being replied to does not exist"))
+			return
+		}
+	} else {
+		chirp, err = qtx.CreateChirp(r.Context(), database.CreateChirpParams{Body: body, UserID: userID, ModerationState: state})
+	}
 	if err != nil {
     fmt.Printf("Error saving chirp: %s", err)
 		w.WriteHeader(500)
 		return
 	}
 
-	
-	w.WriteHeader(201)
-	w.Header().Set("Content-Type", "application/json")
-	resStr, _ := json.Marshal(chirp)
-	w.Write(resStr)
-	return
-}
+	if err = saveTags(r.Context(), qtx, chirp); err != nil {
+		fmt.Printf("Error saving tags: %s", err)
+		w.WriteHeader(500)
+		return
+	}
+	if err = saveMentions(r.Context(), qtx, chirp); err != nil {
+		fmt.Printf("Error saving mentions: %s", err)
+		w.WriteHeader(500)
+		return
+	}
 
-func (cfg *apiConfig) getAllChirps(w http.ResponseWriter, r *http.Request) {
-	type chirp struct {
-		ID        uuid.UUID	`json:"id"`
-		CreatedAt time.Time	`json:"created_at"`
-		UpdatedAt time.Time	`json:"updated_at"`
-		Body      string	`json:"body"`
-		UserID    uuid.UUID	`json:"user_id"`
+	if state == chirpHeld {
+		if err = queueHeldChirp(r.Context(), qtx, chirp.ID, cleaned.Matches); err != nil {
+			fmt.Printf("Error queueing held chirp: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+		if err = logAutoModeration(r.Context(), qtx, moderationActionAutoHold, userID, uuid.NullUUID{UUID: chirp.ID, Valid: true}, cleaned.Matches); err != nil {
+			fmt.Printf("Error logging held chirp: %s", err)
+			w.WriteHeader(500)
+			return
+		}
 	}
 
+	if err = attachMedia(r.Context(), qtx, userID, chirp.ID, requestBody.MediaIDs); errors.Is(err, errInvalidMedia) {
+		w.WriteHeader(400)
+		w.Write([]byte(err.Error()))
+		return
+	} else if err != nil {
+		fmt.Printf("Error attaching media: %s", err)
+		w.WriteHeader(500)
+		return
+	}
 
-	chirps, err := cfg.dbQueries.GetAllChirps(r.Context())
-	if err != nil {
+	if err = tx.Commit(); err != nil {
+		fmt.Printf("Error committing chirp: %s", err)
 		w.WriteHeader(500)
-		w.Write([]byte(fmt.Sprintf("%s", err)))
 		return
-	}	
+	}
 
-	w.WriteHeader(200)
+	readable := []ReadableChirp{DatabaseChirpToReadable(chirp)}
+	if err = cfg.decorateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, readable); err != nil {
+		fmt.Printf("Error decorating chirps: %s", err)
+		w.WriteHeader(500)
+		return
+	}
+
+	// held chirps are saved but wait for review before anyone else sees them
+	status := 201
+	if state == chirpHeld {
+		status = 202
+	}
+	w.WriteHeader(status)
 	w.Header().Set("Content-Type", "application/json")
-	resStr, _ := json.Marshal(chirps)
+	resStr, _ := json.Marshal(readable[0])
 	w.Write(resStr)
 	return
 }
 
+type ChirpPage struct {
+  Chirps      []ReadableChirp   `json:"chirps"`
+  NextCursor  string            `json:"next_cursor,omitempty"`
+  PrevCursor  string            `json:"prev_cursor,omitempty"`
+}
+
+func chirpCursor(chirp database.Chirp) string {
+  return pagination.Cursor{CreatedAt: chirp.CreatedAt, ID: chirp.ID}.Encode()
+}
+
+// chirpPage loads up to limit chirps following cursor in the requested sort
+// order. When backwards is set the page ends just before cursor instead.
+func (cfg *apiConfig) chirpPage(ctx context.Context, authorID, viewer uuid.NullUUID, ascending bool, cursor *pagination.Cursor, backwards bool, limit int32) (ChirpPage, error) {
+  var createdAt sql.NullTime
+  var id uuid.NullUUID
+  if cursor != nil {
+    createdAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+    id = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+  }
+
+  // fetch one extra row to find out whether there is another page
+  var chirps []database.Chirp
+  var err error
+  if ascending != backwards {
+    chirps, err = cfg.dbQueries.GetChirpsAfter(ctx, database.GetChirpsAfterParams{AuthorID: authorID, ViewerID: viewer, CreatedAt: createdAt, ID: id, RowLimit: limit + 1})
+  } else {
+    chirps, err = cfg.dbQueries.GetChirpsBefore(ctx, database.GetChirpsBeforeParams{AuthorID: authorID, ViewerID: viewer, CreatedAt: createdAt, ID: id, RowLimit: limit + 1})
+  }
+  if err != nil {
+    return ChirpPage{}, err
+  }
+
+  hasMore := len(chirps) > int(limit)
+  if hasMore {
+    chirps = chirps[:limit]
+  }
+  if backwards {
+    slices.Reverse(chirps)
+  }
+
+  page := ChirpPage{Chirps: DatabaseChirpsToReadable(chirps)}
+  if len(chirps) == 0 {
+    return page, nil
+  }
+
+  if backwards {
+    page.NextCursor = chirpCursor(chirps[len(chirps)-1])
+    if hasMore {
+      page.PrevCursor = chirpCursor(chirps[0])
+    }
+  } else {
+    if hasMore {
+      page.NextCursor = chirpCursor(chirps[len(chirps)-1])
+    }
+    if cursor != nil {
+      page.PrevCursor = chirpCursor(chirps[0])
+    }
+  }
+  return page, nil
+}
+
+func (cfg *apiConfig) getAllChirps(w http.ResponseWriter, r *http.Request) {
+  query := r.URL.Query()
+
+  limit, err := pagination.ParseLimit(query.Get("limit"))
+  if err != nil {
+    w.WriteHeader(400)
+    w.Write([]byte(err.Error()))
+    return
+  }
+
+  authorID := uuid.NullUUID{}
+  if author := query.Get("author_id"); author != "" {
+    authorID.UUID, err = uuid.Parse(author)
+    if err != nil {
+      w.WriteHeader(400)
+      w.Write([]byte("Invalid author_id"))
+      return
+    }
+    authorID.Valid = true
+  }
+
+  var ascending bool
+  switch strings.ToLower(query.Get("sort")) {
+  case "", "asc":
+    ascending = true
+  case "desc":
+    ascending = false
+  default:
+    w.WriteHeader(400)
+    w.Write([]byte("sort must be asc or desc"))
+    return
+  }
+
+  after, before := query.Get("after"), query.Get("before")
+  if after != "" && before != "" {
+    w.WriteHeader(400)
+    w.Write([]byte("after and before cannot be used together"))
+    return
+  }
+
+  var cursor *pagination.Cursor
+  if encoded := after + before; encoded != "" {
+    decoded, err := pagination.DecodeCursor(encoded)
+    if err != nil {
+      w.WriteHeader(400)
+      w.Write([]byte(err.Error()))
+      return
+    }
+    cursor = &decoded
+  }
+
+  viewer := cfg.optionalUserID(r)
+  page, err := cfg.chirpPage(r.Context(), authorID, viewer, ascending, cursor, before != "", limit)
+  if err != nil {
+    fmt.Printf("Error retrieving chirps: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  if err = cfg.decorateChirps(r.Context(), viewer, page.Chirps); err != nil {
+    fmt.Printf("Error decorating chirps: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  resStr, err := json.Marshal(page)
+  if err != nil {
+    fmt.Printf("Error Marshalling chirps: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
+
+// canSeeChirp holds the rules for who may see a chirp, so that anything
+// served on a chirp's behalf answers the same way getChirp does.
+func (cfg *apiConfig) canSeeChirp(ctx context.Context, chirp database.Chirp, viewer uuid.NullUUID) (bool, error) {
+  if chirp.DeletedAt.Valid || !chirpVisibleTo(chirp, viewer) {
+    return false, nil
+  }
+  if viewer.Valid {
+    blocked, err := cfg.blockedEitherWay(ctx, viewer.UUID, chirp.UserID)
+    if err != nil || blocked {
+      return false, err
+    }
+  }
+  // protected chirps look like they don't exist to anyone not following
+  return cfg.authorVisibleTo(ctx, chirp.UserID, viewer)
+}
+
 func (cfg *apiConfig) getChirp(w http.ResponseWriter, r *http.Request) {
 	requestedId, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
@@ -454,19 +915,335 @@ func (cfg *apiConfig) getChirp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	chirp, err := cfg.dbQueries.GetChirp(r.Context(), requestedId)
-	if err != nil {
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(404)
+		return
+	} else if err != nil {
 		w.WriteHeader(500)
     w.Write([]byte(fmt.Sprintf("%s", err)))
 		return
 	}
 
+	viewer := cfg.optionalUserID(r)
+	if visible, err := cfg.canSeeChirp(r.Context(), chirp, viewer); err != nil {
+		fmt.Printf("Error checking chirp: %s", err)
+		w.WriteHeader(500)
+		return
+	} else if !visible {
+		w.WriteHeader(404)
+		return
+	}
+
+	readable := []ReadableChirp{DatabaseChirpToReadable(chirp)}
+	if err = cfg.decorateChirps(r.Context(), viewer, readable); err != nil {
+		fmt.Printf("Error decorating chirps: %s", err)
+		w.WriteHeader(500)
+		return
+	}
+
 	w.WriteHeader(200)
 	w.Header().Set("Content-Type", "application/json")
-	resStr, _ := json.Marshal(chirp)
+	resStr, _ := json.Marshal(readable[0])
 	w.Write(resStr)
 	return
 }
 
+func (cfg *apiConfig) deleteChirp(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsWrite)
+  if !ok {
+    return
+  }
+
+  chirpID, err := uuid.Parse(r.PathValue("id"))
+  if err != nil {
+    w.WriteHeader(404)
+    return
+  }
+
+  chirp, err := cfg.dbQueries.GetChirp(r.Context(), chirpID)
+  if errors.Is(err, sql.ErrNoRows) || chirp.DeletedAt.Valid {
+    w.WriteHeader(404)
+    return
+  } else if err != nil {
+    fmt.Printf("Error retrieving chirp: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  if chirp.UserID != userID {
+    w.WriteHeader(403)
+    return
+  }
+
+  tx, err := cfg.db.BeginTx(r.Context(), nil)
+  if err != nil {
+    fmt.Printf("Error starting transaction: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  defer tx.Rollback()
+
+  blobs, err := removeChirp(r.Context(), cfg.dbQueries.WithTx(tx), chirp.ID)
+  if err != nil {
+    fmt.Printf("Error deleting chirp: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  if err = tx.Commit(); err != nil {
+    fmt.Printf("Error committing chirp deletion: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  cfg.deleteMediaBlobs(r.Context(), blobs)
+
+  w.WriteHeader(204)
+  return
+}
+
+// removeChirp deletes a chirp along with its rechirps and media. Chirps
+// with replies are tombstoned so the thread keeps its shape. It returns the
+// media blobs to delete once q's changes are committed.
+func removeChirp(ctx context.Context, q *database.Queries, chirpID uuid.UUID) ([]string, error) {
+  if err := q.DeleteRechirps(ctx, uuid.NullUUID{UUID: chirpID, Valid: true}); err != nil {
+    return nil, err
+  }
+
+  hasReplies, err := q.HasReplies(ctx, uuid.NullUUID{UUID: chirpID, Valid: true})
+  if err != nil {
+    return nil, err
+  }
+
+  blobs, err := removeChirpMedia(ctx, q, chirpID)
+  if err != nil {
+    return nil, err
+  }
+
+  if hasReplies {
+    return blobs, tombstoneChirp(ctx, q, chirpID)
+  }
+  return blobs, q.DeleteChirp(ctx, chirpID)
+}
+
+func (cfg *apiConfig) editChirp(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsWrite)
+  if !ok {
+    return
+  }
+
+  chirpID, err := uuid.Parse(r.PathValue("id"))
+  if err != nil {
+    w.WriteHeader(404)
+    return
+  }
+
+  chirp, err := cfg.dbQueries.GetChirp(r.Context(), chirpID)
+  if errors.Is(err, sql.ErrNoRows) || chirp.DeletedAt.Valid || (err == nil && !chirpVisibleTo(chirp, uuid.NullUUID{UUID: userID, Valid: true})) {
+    w.WriteHeader(404)
+    return
+  } else if err != nil {
+    fmt.Printf("Error retrieving chirp: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  if chirp.UserID != userID {
+    w.WriteHeader(403)
+    return
+  }
+
+  if chirp.Kind == chirpKindRechirp {
+    w.WriteHeader(400)
+    w.Write([]byte("Rechirps cannot be edited"))
+    return
+  }
+
+  decoder := json.NewDecoder(r.Body)
+  params := database.UpdateChirpBodyParams{}
+  if err = decoder.Decode(&params); err != nil {
+    fmt.Printf("Error decoding parameters: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  params.ID = chirp.ID
+
+  cleaned, err := cfg.cleanChirpBody(params.Body)
+  if errors.Is(err, errChirpRejected) {
+    if err := logAutoModeration(r.Context(), cfg.dbQueries, moderationActionAutoReject, userID, uuid.NullUUID{UUID: chirp.ID, Valid: true}, cleaned.Matches); err != nil {
+      fmt.Printf("Error logging rejected edit: %s", err)
+      w.WriteHeader(500)
+      return
+    }
+  }
+  if err != nil {
+    w.WriteHeader(400)
+    w.Write([]byte(err.Error()))
+    return
+  }
+  params.Body = cleaned.Text
+
+  if blocked, err := cfg.mentionsBlocked(r.Context(), userID, params.Body); err != nil {
+    fmt.Printf("Error checking blocks: %s", err)
+    w.WriteHeader(500)
+    return
+  } else if blocked {
+    w.WriteHeader(403)
+    w.Write([]byte(errBlocked.Error()))
+    return
+  }
+
+  tx, err := cfg.db.BeginTx(r.Context(), nil)
+  if err != nil {
+    fmt.Printf("Error starting transaction: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  defer tx.Rollback()
+  qtx := cfg.dbQueries.WithTx(tx)
+
+  // lock the chirp so a concurrent edit can't land between saving the old
+  // body and writing the new one
+  chirp, err = qtx.GetChirpForUpdate(r.Context(), chirp.ID)
+  if errors.Is(err, sql.ErrNoRows) || chirp.DeletedAt.Valid {
+    w.WriteHeader(404)
+    return
+  } else if err != nil {
+    fmt.Printf("Error retrieving chirp: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  err = qtx.CreateChirpRevision(r.Context(), database.CreateChirpRevisionParams{ChirpID: chirp.ID, Body: chirp.Body, CreatedAt: chirp.UpdatedAt})
+  if err != nil {
+    fmt.Printf("Error saving chirp revision: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  chirp, err = qtx.UpdateChirpBody(r.Context(), params)
+  if err != nil {
+    fmt.Printf("Error updating chirp: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  // an edit can send a chirp for review, but only a moderator releases it
+  if cleaned.State == chirpHeld && chirp.ModerationState == chirpVisible {
+    if err = qtx.SetChirpModerationState(r.Context(), database.SetChirpModerationStateParams{ID: chirp.ID, ModerationState: chirpHeld}); err != nil {
+      fmt.Printf("Error holding chirp: %s", err)
+      w.WriteHeader(500)
+      return
+    }
+    if err = queueHeldChirp(r.Context(), qtx, chirp.ID, cleaned.Matches); err != nil {
+      fmt.Printf("Error queueing held chirp: %s", err)
+      w.WriteHeader(500)
+      return
+    }
+    if err = logAutoModeration(r.Context(), qtx, moderationActionAutoHold, userID, uuid.NullUUID{UUID: chirp.ID, Valid: true}, cleaned.Matches); err != nil {
+      fmt.Printf("Error logging held chirp: %s", err)
+      w.WriteHeader(500)
+      return
+    }
+    chirp.ModerationState = chirpHeld
+  }
+
+  if err = qtx.DeleteChirpTags(r.Context(), chirp.ID); err != nil {
+    fmt.Printf("Error clearing tags: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  if err = saveTags(r.Context(), qtx, chirp); err != nil {
+    fmt.Printf("Error saving tags: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  if err = qtx.DeleteChirpMentions(r.Context(), chirp.ID); err != nil {
+    fmt.Printf("Error clearing mentions: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  if err = saveMentions(r.Context(), qtx, chirp); err != nil {
+    fmt.Printf("Error saving mentions: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  if err = tx.Commit(); err != nil {
+    fmt.Printf("Error committing chirp update: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  readable := []ReadableChirp{DatabaseChirpToReadable(chirp)}
+  if err = cfg.decorateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, readable); err != nil {
+    fmt.Printf("Error decorating chirps: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  resStr, err := json.Marshal(readable[0])
+  if err != nil {
+    fmt.Printf("Error Marshalling chirp: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
+
+func (cfg *apiConfig) getChirpRevisions(w http.ResponseWriter, r *http.Request) {
+  chirpID, err := uuid.Parse(r.PathValue("id"))
+  if err != nil {
+    w.WriteHeader(404)
+    return
+  }
+
+  viewer := cfg.optionalUserID(r)
+  chirp, err := cfg.dbQueries.GetChirp(r.Context(), chirpID)
+  if errors.Is(err, sql.ErrNoRows) {
+    w.WriteHeader(404)
+    return
+  } else if err != nil {
+    fmt.Printf("Error retrieving chirp: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  if visible, err := cfg.canSeeChirp(r.Context(), chirp, viewer); err != nil {
+    fmt.Printf("Error checking chirp: %s", err)
+    w.WriteHeader(500)
+    return
+  } else if !visible {
+    w.WriteHeader(404)
+    return
+  }
+
+  revisions, err := cfg.dbQueries.GetChirpRevisions(r.Context(), chirpID)
+  if err != nil {
+    fmt.Printf("Error retrieving chirp revisions: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  if revisions == nil {
+    revisions = []database.ChirpRevision{}
+  }
+
+  resStr, err := json.Marshal(revisions)
+  if err != nil {
+    fmt.Printf("Error Marshalling chirp revisions: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
+
 func main() {
 
 	godotenv.Load()
@@ -479,10 +1256,35 @@ func main() {
 
 	dbQueries := database.New(db)
 
+	if len(os.Args) > 1 {
+		if err := runCommand(context.Background(), dbQueries, os.Args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	metrics := &apiConfig{
 		fileserverHits: atomic.Int32{},
+		db: db,
 		dbQueries: dbQueries,
-    jwtSecret: os.Getenv("JWT_SECRET"),
+    keys: auth.NewKeyring(),
+    media: mediaStore(),
+	}
+
+	keyConf, err := signingKeyConfig()
+	if err != nil {
+		panic(err)
+	}
+	metrics.jwtLeeway = keyConf.leeway
+	if err = metrics.refreshSigningKeys(context.Background(), keyConf); err != nil {
+		panic(fmt.Errorf("Error loading signing keys: %s", err))
+	}
+	go metrics.watchSigningKeys(context.Background(), keyConf)
+
+	metrics.moderation, err = loadModeration(context.Background(), dbQueries, os.Getenv("MODERATION_WORDLIST"))
+	if err != nil {
+		panic(fmt.Errorf("Error loading moderation rules: %s", err))
 	}
 
 	metrics.fileserverHits.Store(0)
@@ -494,18 +1296,66 @@ func main() {
 	}
 	mux.Handle("GET /app/", http.StripPrefix("/app", metrics.middlewareMetricsInc(http.FileServer(http.Dir("./site")))))
 	mux.HandleFunc("GET /api/healthz", readiness)
-
-	mux.HandleFunc("GET /admin/metrics", metrics.hitsHandler)
-	mux.HandleFunc("POST /admin/reset", metrics.resetUsers)
+	mux.HandleFunc("GET /.well-known/jwks.json", metrics.getJWKS)
+
+	mux.Handle("GET /admin/metrics", metrics.middlewareRequireRole(auth.RoleAdmin, http.HandlerFunc(metrics.hitsHandler)))
+	mux.Handle("POST /admin/reset", metrics.middlewareRequireRole(auth.RoleAdmin, http.HandlerFunc(metrics.resetUsers)))
+	mux.Handle("PUT /admin/users/{id}/role", metrics.middlewareRequireRole(auth.RoleAdmin, http.HandlerFunc(metrics.setUserRole)))
+	mux.Handle("POST /admin/users/{id}/suspension", metrics.middlewareRequireRole(auth.RoleAdmin, http.HandlerFunc(metrics.suspend)))
+	mux.Handle("DELETE /admin/users/{id}/suspension", metrics.middlewareRequireRole(auth.RoleAdmin, http.HandlerFunc(metrics.unsuspend)))
+	mux.Handle("GET /admin/moderation/reports", metrics.middlewareRequireRole(auth.RoleModerator, http.HandlerFunc(metrics.getReports)))
+	mux.Handle("POST /admin/moderation/reports/{id}/claim", metrics.middlewareRequireRole(auth.RoleModerator, http.HandlerFunc(metrics.claimReport)))
+	mux.Handle("POST /admin/moderation/reports/{id}/resolve", metrics.middlewareRequireRole(auth.RoleModerator, http.HandlerFunc(metrics.resolveReport)))
+	mux.Handle("GET /admin/moderation/log", metrics.middlewareRequireRole(auth.RoleModerator, http.HandlerFunc(metrics.getModerationLog)))
 
 	mux.HandleFunc("POST /api/chirps", metrics.createChirp)
 	mux.HandleFunc("GET /api/chirps", metrics.getAllChirps)
+	mux.HandleFunc("GET /api/chirps/search", metrics.searchChirps)
 	mux.HandleFunc("GET /api/chirps/{id}", metrics.getChirp)
+	mux.HandleFunc("PUT /api/chirps/{id}", metrics.editChirp)
+	mux.HandleFunc("DELETE /api/chirps/{id}", metrics.deleteChirp)
+	mux.HandleFunc("GET /api/chirps/{id}/revisions", metrics.getChirpRevisions)
+	mux.HandleFunc("GET /api/chirps/{id}/thread", metrics.getThread)
+	mux.HandleFunc("POST /api/chirps/{id}/like", metrics.like)
+	mux.HandleFunc("DELETE /api/chirps/{id}/like", metrics.unlike)
+	mux.HandleFunc("POST /api/chirps/{id}/report", metrics.reportChirp)
 	mux.HandleFunc("POST /api/users", metrics.createUser)
 	mux.HandleFunc("PUT /api/users", metrics.changePassword)
+	mux.HandleFunc("POST /api/users/{id}/follow", metrics.follow)
+	mux.HandleFunc("DELETE /api/users/{id}/follow", metrics.unfollow)
+	mux.HandleFunc("GET /api/users/{id}/followers", metrics.getFollowers)
+	mux.HandleFunc("GET /api/users/{id}/following", metrics.getFollowing)
+	mux.HandleFunc("GET /api/follow-requests", metrics.getFollowRequests)
+	mux.HandleFunc("POST /api/follow-requests/{id}/approve", metrics.approveFollowRequest)
+	mux.HandleFunc("POST /api/follow-requests/{id}/deny", metrics.denyFollowRequest)
+	mux.HandleFunc("POST /api/users/{id}/block", metrics.block)
+	mux.HandleFunc("DELETE /api/users/{id}/block", metrics.unblock)
+	mux.HandleFunc("POST /api/users/{id}/mute", metrics.mute)
+	mux.HandleFunc("DELETE /api/users/{id}/mute", metrics.unmute)
+	mux.HandleFunc("GET /api/users/me/security-events", metrics.getSecurityEvents)
+	mux.HandleFunc("GET /api/users/me/blocks", metrics.getBlocks)
+	mux.HandleFunc("GET /api/users/me/mutes", metrics.getMutes)
+	mux.HandleFunc("GET /api/users/me/muted-keywords", metrics.getMutedKeywords)
+	mux.HandleFunc("POST /api/users/me/muted-keywords", metrics.addMutedKeyword)
+	mux.HandleFunc("DELETE /api/users/me/muted-keywords/{keyword}", metrics.removeMutedKeyword)
+	mux.HandleFunc("GET /api/users/me/mentions", metrics.getMyMentions)
+	mux.HandleFunc("PUT /api/users/me/profile", metrics.updateProfile)
+	mux.HandleFunc("GET /api/users/{username}", metrics.getProfile)
+	mux.HandleFunc("GET /api/timeline", metrics.getTimeline)
+	mux.HandleFunc("GET /api/tags/trending", metrics.getTrendingTags)
+	mux.HandleFunc("GET /api/tags/{tag}/chirps", metrics.getTagChirps)
+	mux.HandleFunc("POST /api/media", metrics.uploadMedia)
+	mux.HandleFunc("GET /media/{key}", metrics.serveMedia)
   mux.HandleFunc("POST /api/login", metrics.login)
   mux.HandleFunc("POST /api/refresh", metrics.refresh)
   mux.HandleFunc("POST /api/revoke", metrics.revoke)
+  mux.HandleFunc("GET /api/sessions", metrics.getSessions)
+  mux.HandleFunc("PATCH /api/sessions/{id}", metrics.renameSession)
+  mux.HandleFunc("DELETE /api/sessions/{id}", metrics.revokeSession)
+  mux.HandleFunc("POST /api/sessions/revoke-all", metrics.revokeAllSessions)
+  mux.HandleFunc("GET /api/api-keys", metrics.getAPIKeys)
+  mux.HandleFunc("POST /api/api-keys", metrics.createAPIKey)
+  mux.HandleFunc("DELETE /api/api-keys/{id}", metrics.revokeAPIKey)
 
 	if err := server.ListenAndServe(); err != nil {
 		fmt.Println(err) 
@@ -0,0 +1,460 @@
+package main
+
+import (
+  "bytes"
+  "context"
+  "database/sql"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "io"
+  "net/http"
+  "os"
+
+  "github.com/google/uuid"
+
+  "github.com/j-wut/chirpy/internal/auth"
+  "github.com/j-wut/chirpy/internal/database"
+  "github.com/j-wut/chirpy/internal/imaging"
+  "github.com/j-wut/chirpy/internal/storage"
+)
+
+const (
+  maxMediaSize     = 5 << 20
+  maxMediaPerChirp = 4
+)
+
+// only types that browsers can't be tricked into running as a page
+var mediaExtensions = map[string]string{
+  "image/jpeg": ".jpg",
+  "image/png":  ".png",
+  "image/gif":  ".gif",
+  "image/webp": ".webp",
+  "video/mp4":  ".mp4",
+}
+
+var errInvalidMedia = errors.New("Media must be your own, unattached uploads")
+
+type ReadableMediaVariant struct {
+  Name        string `json:"name"`
+  URL         string `json:"url"`
+  ContentType string `json:"content_type"`
+  Width       int32  `json:"width"`
+  Height      int32  `json:"height"`
+}
+
+type ReadableMedia struct {
+  ID          uuid.UUID              `json:"id"`
+  URL         string                 `json:"url"`
+  ContentType string                 `json:"content_type"`
+  Size        int64                  `json:"size"`
+  Width       int32                  `json:"width,omitempty"`
+  Height      int32                  `json:"height,omitempty"`
+  Blurhash    string                 `json:"blurhash,omitempty"`
+  Variants    []ReadableMediaVariant `json:"variants"`
+}
+
+func DatabaseMediaToReadable(media database.Medium, variants []database.MediaVariant) ReadableMedia {
+  readable := ReadableMedia{
+    ID: media.ID,
+    URL: "/media/" + media.StorageKey,
+    ContentType: media.ContentType,
+    Size: media.SizeBytes,
+    Width: media.Width.Int32,
+    Height: media.Height.Int32,
+    Blurhash: media.Blurhash.String,
+    Variants: []ReadableMediaVariant{},
+  }
+  for _, variant := range variants {
+    readable.Variants = append(readable.Variants, ReadableMediaVariant{
+      Name: variant.Name,
+      URL: "/media/" + variant.StorageKey,
+      ContentType: variant.ContentType,
+      Width: variant.Width,
+      Height: variant.Height,
+    })
+  }
+  return readable
+}
+
+func (cfg *apiConfig) uploadMedia(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsWrite)
+  if !ok {
+    return
+  }
+
+  // leave some room for the multipart framing around the file
+  r.Body = http.MaxBytesReader(w, r.Body, maxMediaSize + 1<<20)
+  file, header, err := r.FormFile("file")
+  var maxBytesErr *http.MaxBytesError
+  if errors.As(err, &maxBytesErr) {
+    w.WriteHeader(413)
+    w.Write([]byte("Media is too large"))
+    return
+  } else if err != nil {
+    w.WriteHeader(400)
+    w.Write([]byte("Expected a multipart upload with a file field"))
+    return
+  }
+  defer file.Close()
+
+  if header.Size > maxMediaSize {
+    w.WriteHeader(413)
+    w.Write([]byte("Media is too large"))
+    return
+  }
+
+  // trust the bytes rather than the client's Content-Type
+  sniff := make([]byte, 512)
+  n, err := io.ReadFull(file, sniff)
+  if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+    w.WriteHeader(400)
+    w.Write([]byte("Could not read upload"))
+    return
+  }
+  contentType := http.DetectContentType(sniff[:n])
+  if _, ok := mediaExtensions[contentType]; !ok {
+    w.WriteHeader(415)
+    w.Write([]byte("Unsupported media type"))
+    return
+  }
+  if _, err = file.Seek(0, io.SeekStart); err != nil {
+    fmt.Printf("Error rewinding upload: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  data, err := io.ReadAll(file)
+  if err != nil {
+    w.WriteHeader(400)
+    w.Write([]byte("Could not read upload"))
+    return
+  }
+
+  processed, err := imaging.Process(data, contentType, imaging.DefaultSizes)
+  if errors.Is(err, imaging.ErrUnsupported) {
+    // video is kept as uploaded
+    processed = &imaging.Processed{
+      Original: imaging.Encoded{Name: "original", ContentType: contentType, Data: data},
+    }
+  } else if errors.Is(err, imaging.ErrTooLarge) {
+    w.WriteHeader(413)
+    w.Write([]byte("Image dimensions are too large"))
+    return
+  } else if err != nil {
+    w.WriteHeader(400)
+    w.Write([]byte("Could not decode image"))
+    return
+  }
+
+  media, variants, err := cfg.storeMedia(r.Context(), userID, processed)
+  if err != nil {
+    fmt.Printf("Error saving media: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  resStr, err := json.Marshal(DatabaseMediaToReadable(media, variants))
+  if err != nil {
+    fmt.Printf("Error Marshalling media: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(201)
+  w.Write(resStr)
+  return
+}
+
+func (cfg *apiConfig) serveMedia(w http.ResponseWriter, r *http.Request) {
+  key := r.PathValue("key")
+  if !storage.ValidKey(key) {
+    w.WriteHeader(404)
+    return
+  }
+
+  media, err := cfg.dbQueries.GetStoredMedia(r.Context(), key)
+  if errors.Is(err, sql.ErrNoRows) {
+    w.WriteHeader(404)
+    return
+  } else if err != nil {
+    fmt.Printf("Error retrieving media: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  // only media anyone can see may be cached by shared caches
+  cacheControl := "public, max-age=31536000, immutable"
+  public, err := cfg.mediaVisibleTo(r.Context(), media.UserID, media.ChirpID, uuid.NullUUID{})
+  if err != nil {
+    fmt.Printf("Error checking media: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  if !public {
+    visible, err := cfg.mediaVisibleTo(r.Context(), media.UserID, media.ChirpID, cfg.optionalUserID(r))
+    if err != nil {
+      fmt.Printf("Error checking media: %s", err)
+      w.WriteHeader(500)
+      return
+    }
+    if !visible {
+      w.WriteHeader(404)
+      return
+    }
+    cacheControl = "private, max-age=60"
+  }
+
+  body, err := cfg.media.Get(r.Context(), key)
+  if errors.Is(err, storage.ErrNotFound) {
+    w.WriteHeader(404)
+    return
+  } else if err != nil {
+    fmt.Printf("Error reading media: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  defer body.Close()
+
+  w.Header().Set("Content-Type", media.ContentType)
+  w.Header().Set("X-Content-Type-Options", "nosniff")
+  w.Header().Set("Cache-Control", cacheControl)
+  w.WriteHeader(200)
+  io.Copy(w, body)
+}
+
+// mediaVisibleTo reports whether viewer may see an upload. Attached media
+// follow the chirp they belong to, and uploads waiting to be attached are
+// only visible to their owner.
+func (cfg *apiConfig) mediaVisibleTo(ctx context.Context, ownerID uuid.UUID, chirpID uuid.NullUUID, viewer uuid.NullUUID) (bool, error) {
+  if !chirpID.Valid {
+    return viewer.Valid && viewer.UUID == ownerID, nil
+  }
+  chirp, err := cfg.dbQueries.GetChirp(ctx, chirpID.UUID)
+  if errors.Is(err, sql.ErrNoRows) {
+    return false, nil
+  } else if err != nil {
+    return false, err
+  }
+  return cfg.canSeeChirp(ctx, chirp, viewer)
+}
+
+// checkMedia makes sure every ID is an unattached upload owned by userID.
+func (cfg *apiConfig) checkMedia(ctx context.Context, userID uuid.UUID, mediaIDs []uuid.UUID) error {
+  if len(mediaIDs) == 0 {
+    return nil
+  }
+  if len(mediaIDs) > maxMediaPerChirp {
+    return fmt.Errorf("A chirp can have at most %d media", maxMediaPerChirp)
+  }
+
+  seen := map[uuid.UUID]bool{}
+  for _, id := range mediaIDs {
+    if seen[id] {
+      return errInvalidMedia
+    }
+    seen[id] = true
+  }
+
+  found, err := cfg.dbQueries.GetUnattachedMedia(ctx, database.GetUnattachedMediaParams{Ids: mediaIDs, UserID: userID})
+  if err != nil {
+    return err
+  }
+  if len(found) != len(mediaIDs) {
+    return errInvalidMedia
+  }
+  return nil
+}
+
+// attachMedia attaches uploads to a chirp in order. It goes through q so
+// the chirp and its media are saved together.
+func attachMedia(ctx context.Context, q *database.Queries, userID, chirpID uuid.UUID, mediaIDs []uuid.UUID) error {
+  for i, id := range mediaIDs {
+    attached, err := q.AttachMedia(ctx, database.AttachMediaParams{
+      ChirpID: uuid.NullUUID{UUID: chirpID, Valid: true},
+      Position: int32(i),
+      ID: id,
+      UserID: userID,
+    })
+    if err != nil {
+      return err
+    }
+    if attached != 1 {
+      return errInvalidMedia
+    }
+  }
+  return nil
+}
+
+// addMedia fills in the attachments of each chirp.
+func (cfg *apiConfig) addMedia(ctx context.Context, chirps []ReadableChirp) error {
+  ids := liveChirpIDs(chirps)
+  if len(ids) == 0 {
+    return nil
+  }
+
+  media, err := cfg.dbQueries.GetChirpMedia(ctx, ids)
+  if err != nil || len(media) == 0 {
+    return err
+  }
+
+  mediaIDs := make([]uuid.UUID, 0, len(media))
+  for _, m := range media {
+    mediaIDs = append(mediaIDs, m.ID)
+  }
+  variants, err := cfg.dbQueries.GetMediaVariants(ctx, mediaIDs)
+  if err != nil {
+    return err
+  }
+  byMedia := map[uuid.UUID][]database.MediaVariant{}
+  for _, variant := range variants {
+    byMedia[variant.MediaID] = append(byMedia[variant.MediaID], variant)
+  }
+
+  byChirp := map[uuid.UUID][]ReadableMedia{}
+  for _, m := range media {
+    byChirp[m.ChirpID.UUID] = append(byChirp[m.ChirpID.UUID], DatabaseMediaToReadable(m, byMedia[m.ID]))
+  }
+
+  for i := range chirps {
+    if found, ok := byChirp[chirps[i].ID]; ok {
+      chirps[i].Media = found
+    }
+  }
+  return nil
+}
+
+// storeMedia saves the processed upload and its thumbnails, cleaning up
+// whatever was already stored if any step fails. Keys follow the processed
+// type, which isn't always the uploaded one.
+func (cfg *apiConfig) storeMedia(ctx context.Context, userID uuid.UUID, processed *imaging.Processed) (database.Medium, []database.MediaVariant, error) {
+  mediaID := uuid.New()
+  stored := []string{}
+  fail := func(err error) (database.Medium, []database.MediaVariant, error) {
+    for _, key := range stored {
+      cfg.media.Delete(ctx, key)
+    }
+    return database.Medium{}, nil, err
+  }
+
+  put := func(key string, encoded imaging.Encoded) error {
+    if err := cfg.media.Put(ctx, key, bytes.NewReader(encoded.Data), int64(len(encoded.Data)), encoded.ContentType); err != nil {
+      return err
+    }
+    stored = append(stored, key)
+    return nil
+  }
+
+  original := processed.Original
+  key := mediaID.String() + mediaExtensions[original.ContentType]
+  if err := put(key, original); err != nil {
+    return fail(err)
+  }
+
+  variantKeys := make([]string, len(processed.Variants))
+  for i, variant := range processed.Variants {
+    variantKeys[i] = mediaID.String() + "_" + variant.Name + mediaExtensions[variant.ContentType]
+    if err := put(variantKeys[i], variant); err != nil {
+      return fail(err)
+    }
+  }
+
+  tx, err := cfg.db.BeginTx(ctx, nil)
+  if err != nil {
+    return fail(err)
+  }
+  defer tx.Rollback()
+  qtx := cfg.dbQueries.WithTx(tx)
+
+  media, err := qtx.CreateMedia(ctx, database.CreateMediaParams{
+    ID: mediaID,
+    UserID: userID,
+    ContentType: original.ContentType,
+    SizeBytes: int64(len(original.Data)),
+    StorageKey: key,
+    Width: sql.NullInt32{Int32: int32(original.Width), Valid: original.Width > 0},
+    Height: sql.NullInt32{Int32: int32(original.Height), Valid: original.Height > 0},
+    Blurhash: sql.NullString{String: processed.Blurhash, Valid: processed.Blurhash != ""},
+  })
+  if err != nil {
+    return fail(err)
+  }
+
+  variants := []database.MediaVariant{}
+  for i, variant := range processed.Variants {
+    saved, err := qtx.CreateMediaVariant(ctx, database.CreateMediaVariantParams{
+      MediaID: mediaID,
+      Name: variant.Name,
+      ContentType: variant.ContentType,
+      SizeBytes: int64(len(variant.Data)),
+      StorageKey: variantKeys[i],
+      Width: int32(variant.Width),
+      Height: int32(variant.Height),
+    })
+    if err != nil {
+      return fail(err)
+    }
+    variants = append(variants, saved)
+  }
+
+  if err = tx.Commit(); err != nil {
+    return fail(err)
+  }
+  return media, variants, nil
+}
+
+// removeChirpMedia deletes the rows for a chirp's media and returns the blob
+// keys, which should only be deleted once the rows are gone for good.
+func removeChirpMedia(ctx context.Context, q *database.Queries, chirpID uuid.UUID) ([]string, error) {
+  chirp := uuid.NullUUID{UUID: chirpID, Valid: true}
+  variantKeys, err := q.DeleteChirpMediaVariants(ctx, chirp)
+  if err != nil {
+    return nil, err
+  }
+  keys, err := q.DeleteChirpMedia(ctx, chirp)
+  if err != nil {
+    return nil, err
+  }
+  return append(keys, variantKeys...), nil
+}
+
+// deleteMediaBlobs removes stored media once its rows are gone.
+func (cfg *apiConfig) deleteMediaBlobs(ctx context.Context, keys []string) {
+  for _, key := range keys {
+    // a leftover blob is unreachable without its row, so only log it
+    if err := cfg.media.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+      fmt.Printf("Error deleting media blob %s: %s", key, err)
+    }
+  }
+}
+
+// mediaStore picks the blob backend from MEDIA_STORAGE, defaulting to a
+// local directory.
+func mediaStore() storage.Store {
+  switch os.Getenv("MEDIA_STORAGE") {
+  case "s3":
+    store, err := storage.NewS3Store(
+      os.Getenv("S3_ENDPOINT"),
+      os.Getenv("S3_BUCKET"),
+      os.Getenv("S3_REGION"),
+      os.Getenv("S3_ACCESS_KEY_ID"),
+      os.Getenv("S3_SECRET_ACCESS_KEY"),
+    )
+    if err != nil {
+      panic(fmt.Errorf("Error configuring S3 storage: %s", err))
+    }
+    return store
+  case "", "fs":
+    dir := os.Getenv("MEDIA_DIR")
+    if dir == "" {
+      dir = "./media"
+    }
+    store, err := storage.NewFileStore(dir)
+    if err != nil {
+      panic(fmt.Errorf("Error configuring media directory: %s", err))
+    }
+    return store
+  default:
+    panic(fmt.Errorf("Unknown MEDIA_STORAGE: %s", os.Getenv("MEDIA_STORAGE")))
+  }
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+  "context"
+  "database/sql"
+  "encoding/json"
+  "fmt"
+  "net/http"
+  "strings"
+
+  "github.com/google/uuid"
+
+  "github.com/j-wut/chirpy/internal/auth"
+  "github.com/j-wut/chirpy/internal/database"
+  "github.com/j-wut/chirpy/internal/entities"
+  "github.com/j-wut/chirpy/internal/pagination"
+)
+
+type Entity struct {
+  Type      string    `json:"type"`
+  UserID    uuid.UUID `json:"user_id"`
+  Username  string    `json:"username"`
+  Start     int       `json:"start"`
+  End       int       `json:"end"`
+}
+
+// saveMentions records the users mentioned in a chirp's body. Mentions of
+// usernames that don't exist are ignored. Callers editing a chirp should
+// clear its old mentions first.
+func saveMentions(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+  // nobody is notified about a chirp until it's released
+  if chirp.ModerationState != chirpVisible {
+    return nil
+  }
+  mentions := entities.Mentions(chirp.Body)
+  if len(mentions) == 0 {
+    return nil
+  }
+
+  usernames := []string{}
+  for _, mention := range mentions {
+    usernames = append(usernames, strings.ToLower(mention.Username))
+  }
+
+  users, err := q.GetUsersByUsernames(ctx, usernames)
+  if err != nil {
+    return err
+  }
+  userIDs := map[string]uuid.UUID{}
+  for _, user := range users {
+    userIDs[strings.ToLower(user.Username)] = user.ID
+  }
+
+  for _, mention := range mentions {
+    userID, ok := userIDs[strings.ToLower(mention.Username)]
+    if !ok {
+      continue
+    }
+    err = q.CreateChirpMention(ctx, database.CreateChirpMentionParams{
+      ChirpID: chirp.ID,
+      UserID: userID,
+      StartOffset: int32(mention.Start),
+      EndOffset: int32(mention.End),
+    })
+    if err != nil {
+      return err
+    }
+  }
+  return nil
+}
+
+// addMentions fills in the entities array of each chirp.
+func (cfg *apiConfig) addMentions(ctx context.Context, chirps []ReadableChirp) error {
+  ids := liveChirpIDs(chirps)
+  if len(ids) == 0 {
+    return nil
+  }
+
+  mentions, err := cfg.dbQueries.GetChirpMentions(ctx, ids)
+  if err != nil {
+    return err
+  }
+
+  byChirp := map[uuid.UUID][]Entity{}
+  for _, mention := range mentions {
+    byChirp[mention.ChirpID] = append(byChirp[mention.ChirpID], Entity{
+      Type: "mention",
+      UserID: mention.UserID,
+      Username: mention.Username,
+      Start: int(mention.StartOffset),
+      End: int(mention.EndOffset),
+    })
+  }
+
+  for i := range chirps {
+    if found, ok := byChirp[chirps[i].ID]; ok {
+      chirps[i].Entities = found
+    }
+  }
+  return nil
+}
+
+func (cfg *apiConfig) getMyMentions(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsRead)
+  if !ok {
+    return
+  }
+
+  query := r.URL.Query()
+  limit, err := pagination.ParseLimit(query.Get("limit"))
+  if err != nil {
+    w.WriteHeader(400)
+    w.Write([]byte(err.Error()))
+    return
+  }
+
+  params := database.GetMentionChirpsParams{UserID: userID, RowLimit: limit + 1}
+  if after := query.Get("after"); after != "" {
+    cursor, err := pagination.DecodeCursor(after)
+    if err != nil {
+      w.WriteHeader(400)
+      w.Write([]byte(err.Error()))
+      return
+    }
+    params.CreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+    params.ID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+  }
+
+  chirps, err := cfg.dbQueries.GetMentionChirps(r.Context(), params)
+  if err != nil {
+    fmt.Printf("Error retrieving mentions: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  page := ChirpPage{}
+  if len(chirps) > int(limit) {
+    chirps = chirps[:limit]
+    page.NextCursor = chirpCursor(chirps[len(chirps)-1])
+  }
+  page.Chirps = DatabaseChirpsToReadable(chirps)
+
+  if err = cfg.decorateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, page.Chirps); err != nil {
+    fmt.Printf("Error decorating chirps: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  resStr, err := json.Marshal(page)
+  if err != nil {
+    fmt.Printf("Error Marshalling chirps: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "os"
+
+  "github.com/google/uuid"
+
+  "github.com/j-wut/chirpy/internal/database"
+  "github.com/j-wut/chirpy/internal/moderation"
+)
+
+// moderation states of a chirp; only visible chirps appear in listings
+const (
+  chirpVisible = "visible"
+  chirpHeld    = "held"
+  chirpHidden  = "hidden"
+)
+
+var errChirpRejected = errors.New("Chirp was rejected by content rules")
+
+// the original hard-coded list, used when no word list file is configured
+var defaultModerationWords = map[string]moderation.Action{
+  "kerfuffle": moderation.Mask,
+  "sharbert":  moderation.Mask,
+  "fornax":    moderation.Mask,
+}
+
+// loadModeration builds the chirp filter from the word list at path, or the
+// default words if path is empty, followed by the rules stored in the
+// database. It only runs at startup, so changes to either take effect on the
+// next restart.
+func loadModeration(ctx context.Context, q *database.Queries, path string) (moderation.Filter, error) {
+  words := moderation.NewWordList(defaultModerationWords)
+  if path != "" {
+    file, err := os.Open(path)
+    if err != nil {
+      return nil, err
+    }
+    defer file.Close()
+    if words, err = moderation.LoadWordList(file); err != nil {
+      return nil, fmt.Errorf("%s: %w", path, err)
+    }
+  }
+  chain := moderation.Chain{words}
+
+  rules, err := q.GetModerationRules(ctx)
+  if err != nil {
+    return nil, err
+  }
+  dbWords := map[string]moderation.Action{}
+  for _, rule := range rules {
+    action, err := moderation.ParseAction(rule.Action)
+    if err != nil {
+      return nil, fmt.Errorf("rule %s: %w", rule.ID, err)
+    }
+    switch rule.Kind {
+    case "word":
+      if err := moderation.CheckWord(rule.Pattern); err != nil {
+        return nil, fmt.Errorf("rule %s: %w", rule.ID, err)
+      }
+      dbWords[rule.Pattern] = action
+    case "regex":
+      regex, err := moderation.NewRegexRule(rule.ID.String(), rule.Pattern, action)
+      if err != nil {
+        return nil, fmt.Errorf("rule %s: %w", rule.ID, err)
+      }
+      chain = append(chain, regex)
+    }
+  }
+  if len(dbWords) > 0 {
+    chain = append(chain, moderation.NewWordList(dbWords))
+  }
+  return chain, nil
+}
+
+// cleanedBody is a chirp body that made it through moderation, along with
+// the state the chirp should be saved in.
+type cleanedBody struct {
+  Text    string
+  State   string
+  Matches []string
+}
+
+// cleanChirpBody enforces the length limit and runs the moderation filters.
+// It is shared by every path that writes a chirp body. A rejected body still
+// reports what it matched, for the moderation log.
+func (cfg *apiConfig) cleanChirpBody(body string) (cleanedBody, error) {
+  if len(body) > 140 {
+    return cleanedBody{}, errChirpTooLong
+  }
+
+  res := cfg.moderation.Filter(body)
+  cleaned := cleanedBody{Text: res.Body, State: chirpVisible, Matches: res.Matches}
+  switch res.Action {
+  case moderation.Reject:
+    return cleanedBody{Matches: res.Matches}, errChirpRejected
+  case moderation.Hold:
+    cleaned.State = chirpHeld
+  }
+  return cleaned, nil
+}
+
+// chirpVisibleTo reports whether viewer may see chirp. Authors can see
+// their own chirps while they wait for review, but not once a moderator has
+// hidden them.
+func chirpVisibleTo(chirp database.Chirp, viewer uuid.NullUUID) bool {
+  switch chirp.ModerationState {
+  case chirpVisible:
+    return true
+  case chirpHeld:
+    return viewer.Valid && viewer.UUID == chirp.UserID
+  default:
+    return false
+  }
+}
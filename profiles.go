@@ -0,0 +1,185 @@
+package main
+
+import (
+  "database/sql"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "net/http"
+  "net/url"
+  "time"
+  "unicode/utf8"
+
+  "github.com/google/uuid"
+
+  "github.com/j-wut/chirpy/internal/auth"
+  "github.com/j-wut/chirpy/internal/database"
+)
+
+const (
+  maxDisplayNameLength  = 50
+  maxBioLength          = 160
+  maxAvatarURLLength    = 2048
+)
+
+type ProfileRequest struct {
+  DisplayName   string  `json:"display_name"`
+  Bio           string  `json:"bio"`
+  AvatarURL     string  `json:"avatar_url"`
+  // left out keeps the current setting
+  Protected     *bool   `json:"protected"`
+}
+
+// PublicProfile is everything anyone can see about a user. It must never
+// carry the email address.
+type PublicProfile struct {
+  ID              uuid.UUID `json:"id"`
+  CreatedAt       time.Time `json:"created_at"`
+  Username        string    `json:"username"`
+  DisplayName     string    `json:"display_name"`
+  Bio             string    `json:"bio"`
+  AvatarURL       string    `json:"avatar_url"`
+  Protected       bool      `json:"protected"`
+  FollowerCount   int64     `json:"follower_count"`
+  FollowingCount  int64     `json:"following_count"`
+  ChirpCount      int64     `json:"chirp_count"`
+}
+
+func validateProfile(profile ProfileRequest) error {
+  if utf8.RuneCountInString(profile.DisplayName) > maxDisplayNameLength {
+    return fmt.Errorf("Display name can be at most %d characters", maxDisplayNameLength)
+  }
+  if utf8.RuneCountInString(profile.Bio) > maxBioLength {
+    return fmt.Errorf("Bio can be at most %d characters", maxBioLength)
+  }
+  if profile.AvatarURL != "" {
+    avatar, err := url.Parse(profile.AvatarURL)
+    if err != nil || (avatar.Scheme != "http" && avatar.Scheme != "https") || avatar.Host == "" || len(profile.AvatarURL) > maxAvatarURLLength {
+      return errors.New("Avatar URL must be an http or https URL")
+    }
+  }
+  return nil
+}
+
+func (cfg *apiConfig) updateProfile(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeProfileWrite)
+  if !ok {
+    return
+  }
+
+  decoder := json.NewDecoder(r.Body)
+  requestBody := ProfileRequest{}
+  if err := decoder.Decode(&requestBody); err != nil {
+    fmt.Printf("Error decoding parameters: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  if err := validateProfile(requestBody); err != nil {
+    w.WriteHeader(400)
+    w.Write([]byte(err.Error()))
+    return
+  }
+
+  tx, err := cfg.db.BeginTx(r.Context(), nil)
+  if err != nil {
+    fmt.Printf("Error starting transaction: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  defer tx.Rollback()
+  qtx := cfg.dbQueries.WithTx(tx)
+
+  current, err := qtx.GetUserByID(r.Context(), userID)
+  if errors.Is(err, sql.ErrNoRows) {
+    w.WriteHeader(401)
+    return
+  } else if err != nil {
+    fmt.Printf("Error retrieving user: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  protected := current.Protected
+  if requestBody.Protected != nil {
+    protected = *requestBody.Protected
+  }
+
+  user, err := qtx.UpdateProfile(r.Context(), database.UpdateProfileParams{
+    ID: userID,
+    DisplayName: requestBody.DisplayName,
+    Bio: requestBody.Bio,
+    AvatarUrl: requestBody.AvatarURL,
+    Protected: protected,
+  })
+  if errors.Is(err, sql.ErrNoRows) {
+    w.WriteHeader(401)
+    return
+  } else if err != nil {
+    fmt.Printf("Error updating profile: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  // nobody is left waiting once an account stops being protected, but only
+  // an explicit switch off lets them in
+  if current.Protected && !user.Protected {
+    if err = qtx.ApproveAllFollowRequests(r.Context(), user.ID); err != nil {
+      fmt.Printf("Error approving follow requests: %s", err)
+      w.WriteHeader(500)
+      return
+    }
+  }
+
+  if err = tx.Commit(); err != nil {
+    fmt.Printf("Error committing profile: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  resStr, err := json.Marshal(DatabaseUserToReadable(user))
+  if err != nil {
+    fmt.Printf("Error Marshalling user: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
+
+func (cfg *apiConfig) getProfile(w http.ResponseWriter, r *http.Request) {
+  profile, err := cfg.dbQueries.GetPublicProfile(r.Context(), r.PathValue("username"))
+  if errors.Is(err, sql.ErrNoRows) {
+    w.WriteHeader(404)
+    return
+  } else if err != nil {
+    fmt.Printf("Error retrieving profile: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  resStr, err := json.Marshal(PublicProfile{
+    ID: profile.ID,
+    CreatedAt: profile.CreatedAt,
+    Username: profile.Username,
+    DisplayName: profile.DisplayName,
+    Bio: profile.Bio,
+    AvatarURL: profile.AvatarUrl,
+    Protected: profile.Protected,
+    FollowerCount: profile.FollowerCount,
+    FollowingCount: profile.FollowingCount,
+    ChirpCount: profile.ChirpCount,
+  })
+  if err != nil {
+    fmt.Printf("Error Marshalling profile: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
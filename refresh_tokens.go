@@ -0,0 +1,160 @@
+package main
+
+import (
+  "database/sql"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "net"
+  "net/http"
+  "time"
+
+  "github.com/google/uuid"
+
+  "github.com/j-wut/chirpy/internal/auth"
+  "github.com/j-wut/chirpy/internal/database"
+)
+
+const (
+  refreshTokenLifetime  = 60 * 24 * time.Hour
+  securityEventsLimit   = 50
+
+  securityEventRefreshTokenReuse = "refresh_token_reuse"
+)
+
+var errRefreshTokenReused = errors.New("Refresh token has already been used")
+
+type SecurityEvent struct {
+  ID          uuid.UUID `json:"id"`
+  CreatedAt   time.Time `json:"created_at"`
+  Kind        string    `json:"kind"`
+  IPAddress   string    `json:"ip_address"`
+  UserAgent   string    `json:"user_agent"`
+  Details     string    `json:"details"`
+}
+
+// clientIP is the address the request came from, without the port.
+func clientIP(r *http.Request) string {
+  host, _, err := net.SplitHostPort(r.RemoteAddr)
+  if err != nil {
+    return r.RemoteAddr
+  }
+  return host
+}
+
+// issueRefreshToken saves a new refresh token for userID, noting the client
+// that made r. Every token rotated out of it shares familyID, which is the
+// session a user sees and can revoke.
+func issueRefreshToken(r *http.Request, q *database.Queries, userID, familyID uuid.UUID, sessionName string) (string, error) {
+  token, err := auth.MakeRefreshToken()
+  if err != nil {
+    return "", err
+  }
+  _, err = q.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
+    Token: token,
+    ExpiresAt: time.Now().Add(refreshTokenLifetime),
+    UserID: userID,
+    FamilyID: familyID,
+    UserAgent: r.UserAgent(),
+    IpAddress: clientIP(r),
+    SessionName: sessionName,
+  })
+  return token, err
+}
+
+// rotateRefreshToken retires old and issues its replacement, which carries on
+// the same session as seen from the client making r. It fails with
+// errRefreshTokenReused if old was rotated by someone else in the meantime.
+func (cfg *apiConfig) rotateRefreshToken(r *http.Request, old database.RefreshToken) (string, error) {
+  ctx := r.Context()
+  tx, err := cfg.db.BeginTx(ctx, nil)
+  if err != nil {
+    return "", err
+  }
+  defer tx.Rollback()
+  qtx := cfg.dbQueries.WithTx(tx)
+
+  token, err := issueRefreshToken(r, qtx, old.UserID, old.FamilyID, old.SessionName)
+  if err != nil {
+    return "", err
+  }
+  rotated, err := qtx.RotateRefreshToken(ctx, database.RotateRefreshTokenParams{Token: old.Token, ReplacedBy: sql.NullString{String: token, Valid: true}})
+  if err != nil {
+    return "", err
+  }
+  if rotated == 0 {
+    return "", errRefreshTokenReused
+  }
+  return token, tx.Commit()
+}
+
+// refreshTokenReused revokes every token descended from the same login as
+// token. Only a copy of the token can be presented after it was rotated, so
+// the whole family has to be treated as stolen. The revocation and the event
+// telling the owner about it land together.
+func (cfg *apiConfig) refreshTokenReused(r *http.Request, token database.RefreshToken) error {
+  tx, err := cfg.db.BeginTx(r.Context(), nil)
+  if err != nil {
+    return err
+  }
+  defer tx.Rollback()
+  qtx := cfg.dbQueries.WithTx(tx)
+
+  if err = qtx.RevokeRefreshTokenFamily(r.Context(), token.FamilyID); err != nil {
+    return err
+  }
+  err = qtx.CreateSecurityEvent(r.Context(), securityEventParams(r, token.UserID, securityEventRefreshTokenReuse, fmt.Sprintf("refresh token family %s revoked", token.FamilyID)))
+  if err != nil {
+    return err
+  }
+  return tx.Commit()
+}
+
+// recordSecurityEvent notes something the account owner should know about.
+// Failing to record it doesn't fail the request.
+func (cfg *apiConfig) recordSecurityEvent(r *http.Request, userID uuid.UUID, kind, details string) {
+  if err := cfg.dbQueries.CreateSecurityEvent(r.Context(), securityEventParams(r, userID, kind, details)); err != nil {
+    fmt.Printf("Error recording security event: %s", err)
+  }
+}
+
+func securityEventParams(r *http.Request, userID uuid.UUID, kind, details string) database.CreateSecurityEventParams {
+  return database.CreateSecurityEventParams{
+    UserID: userID,
+    Kind: kind,
+    IpAddress: clientIP(r),
+    UserAgent: r.UserAgent(),
+    Details: details,
+  }
+}
+
+func (cfg *apiConfig) getSecurityEvents(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeAccount)
+  if !ok {
+    return
+  }
+
+  events, err := cfg.dbQueries.GetSecurityEvents(r.Context(), database.GetSecurityEventsParams{UserID: userID, RowLimit: securityEventsLimit})
+  if err != nil {
+    fmt.Printf("Error retrieving security events: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  readable := []SecurityEvent{}
+  for _, e := range events {
+    readable = append(readable, SecurityEvent{e.ID, e.CreatedAt, e.Kind, e.IpAddress, e.UserAgent, e.Details})
+  }
+
+  resStr, err := json.Marshal(readable)
+  if err != nil {
+    fmt.Printf("Error Marshalling security events: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
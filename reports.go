@@ -0,0 +1,572 @@
+package main
+
+import (
+  "context"
+  "database/sql"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "net/http"
+  "strings"
+  "time"
+
+  "github.com/google/uuid"
+
+  "github.com/j-wut/chirpy/internal/auth"
+  "github.com/j-wut/chirpy/internal/database"
+  "github.com/j-wut/chirpy/internal/pagination"
+)
+
+var reportReasons = map[string]bool{
+  "spam":           true,
+  "harassment":     true,
+  "hate":           true,
+  "violence":       true,
+  "sexual":         true,
+  "self_harm":      true,
+  "misinformation": true,
+  "other":          true,
+}
+
+// the reason given to reports the moderation pipeline files itself
+const reportReasonHeld = "held"
+
+// actions the moderation pipeline logs on its own
+const (
+  moderationActionAutoHold   = "auto_hold"
+  moderationActionAutoReject = "auto_reject"
+)
+
+const (
+  reportOpen     = "open"
+  reportClaimed  = "claimed"
+  reportResolved = "resolved"
+)
+
+// what a moderator can do when resolving a report
+const (
+  resolutionDismiss     = "dismiss"
+  resolutionHideChirp   = "hide_chirp"
+  resolutionDeleteChirp = "delete_chirp"
+  resolutionWarnUser    = "warn_user"
+  resolutionSuspendUser = "suspend_user"
+)
+
+type ReportRequest struct {
+  Reason  string `json:"reason"`
+  Details string `json:"details"`
+}
+
+type ResolveRequest struct {
+  Action      string `json:"action"`
+  Note        string `json:"note"`
+  // only used when suspending; zero suspends indefinitely
+  SuspendDays int    `json:"suspend_days"`
+  HideChirps  bool   `json:"hide_chirps"`
+}
+
+type ReadableReport struct {
+  ID         uuid.UUID     `json:"id"`
+  CreatedAt  time.Time     `json:"created_at"`
+  ChirpID    uuid.NullUUID `json:"chirp_id"`
+  AuthorID   uuid.UUID     `json:"author_id"`
+  ChirpBody  string        `json:"chirp_body"`
+  ReporterID uuid.NullUUID `json:"reporter_id"`
+  Reason     string        `json:"reason"`
+  Details    string        `json:"details"`
+  Status     string        `json:"status"`
+  ClaimedBy  uuid.NullUUID `json:"claimed_by"`
+  ClaimedAt  *time.Time    `json:"claimed_at,omitempty"`
+  ResolvedBy uuid.NullUUID `json:"resolved_by"`
+  ResolvedAt *time.Time    `json:"resolved_at,omitempty"`
+  Resolution string        `json:"resolution,omitempty"`
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+  if !t.Valid {
+    return nil
+  }
+  return &t.Time
+}
+
+func DatabaseReportToReadable(report database.Report) ReadableReport {
+  return ReadableReport{
+    ID: report.ID,
+    CreatedAt: report.CreatedAt,
+    ChirpID: report.ChirpID,
+    AuthorID: report.AuthorID,
+    ChirpBody: report.ChirpBody,
+    ReporterID: report.ReporterID,
+    Reason: report.Reason,
+    Details: report.Details,
+    Status: report.Status,
+    ClaimedBy: report.ClaimedBy,
+    ClaimedAt: nullTimePtr(report.ClaimedAt),
+    ResolvedBy: report.ResolvedBy,
+    ResolvedAt: nullTimePtr(report.ResolvedAt),
+    Resolution: report.Resolution.String,
+  }
+}
+
+type ReportPage struct {
+  Reports    []ReadableReport `json:"reports"`
+  NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+type ModerationLogEntry struct {
+  ID            uuid.UUID     `json:"id"`
+  CreatedAt     time.Time     `json:"created_at"`
+  ModeratorID   uuid.NullUUID `json:"moderator_id"`
+  ReportID      uuid.NullUUID `json:"report_id"`
+  Action        string        `json:"action"`
+  TargetUserID  uuid.NullUUID `json:"target_user_id"`
+  TargetChirpID uuid.NullUUID `json:"target_chirp_id"`
+  Note          string        `json:"note"`
+}
+
+type ModerationLogPage struct {
+  Entries    []ModerationLogEntry `json:"entries"`
+  NextCursor string               `json:"next_cursor,omitempty"`
+}
+
+// logAutoModeration records a hold or rejection made by the moderation
+// pipeline. There's no moderator behind it, and a rejected chirp was never
+// saved, so only the author is named.
+func logAutoModeration(ctx context.Context, q *database.Queries, action string, userID uuid.UUID, chirpID uuid.NullUUID, matches []string) error {
+  return q.CreateModerationLogEntry(ctx, database.CreateModerationLogEntryParams{
+    Action: action,
+    TargetUserID: uuid.NullUUID{UUID: userID, Valid: true},
+    TargetChirpID: chirpID,
+    Note: "matched " + strings.Join(matches, ", "),
+  })
+}
+
+// queueHeldChirp files the report that puts a chirp held by the moderation
+// pipeline in front of a moderator.
+func queueHeldChirp(ctx context.Context, q *database.Queries, chirpID uuid.UUID, matches []string) error {
+  _, err := q.CreateReport(ctx, database.CreateReportParams{
+    Reason: reportReasonHeld,
+    Details: "matched " + strings.Join(matches, ", "),
+    ChirpID: chirpID,
+  })
+  return err
+}
+
+func (cfg *apiConfig) reportChirp(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsWrite)
+  if !ok {
+    return
+  }
+
+  chirpID, err := uuid.Parse(r.PathValue("id"))
+  if err != nil {
+    w.WriteHeader(404)
+    return
+  }
+
+  chirp, err := cfg.dbQueries.GetChirp(r.Context(), chirpID)
+  viewer := uuid.NullUUID{UUID: userID, Valid: true}
+  if errors.Is(err, sql.ErrNoRows) || chirp.DeletedAt.Valid || (err == nil && !chirpVisibleTo(chirp, viewer)) {
+    w.WriteHeader(404)
+    return
+  } else if err != nil {
+    fmt.Printf("Error retrieving chirp: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  if chirp.UserID == userID {
+    w.WriteHeader(400)
+    w.Write([]byte("You cannot report your own chirp"))
+    return
+  }
+
+  decoder := json.NewDecoder(r.Body)
+  requestBody := ReportRequest{}
+  if err = decoder.Decode(&requestBody); err != nil {
+    fmt.Printf("Error decoding parameters: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  if !reportReasons[requestBody.Reason] {
+    w.WriteHeader(400)
+    w.Write([]byte("Unknown report reason"))
+    return
+  }
+  if len(requestBody.Details) > 1000 {
+    w.WriteHeader(400)
+    w.Write([]byte("Report details are too long"))
+    return
+  }
+
+  report, err := cfg.dbQueries.CreateReport(r.Context(), database.CreateReportParams{
+    ReporterID: viewer,
+    Reason: requestBody.Reason,
+    Details: requestBody.Details,
+    ChirpID: chirp.ID,
+  })
+  if isUniqueViolation(err) {
+    w.WriteHeader(409)
+    w.Write([]byte("You have already reported this chirp"))
+    return
+  } else if err != nil {
+    fmt.Printf("Error saving report: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  // reporters only get confirmation, not the moderation details
+  resStr, err := json.Marshal(struct {
+    ID     uuid.UUID `json:"id"`
+    Status string    `json:"status"`
+  }{report.ID, report.Status})
+  if err != nil {
+    fmt.Printf("Error Marshalling report: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(201)
+  w.Write(resStr)
+  return
+}
+
+func (cfg *apiConfig) getReports(w http.ResponseWriter, r *http.Request) {
+  query := r.URL.Query()
+  limit, err := pagination.ParseLimit(query.Get("limit"))
+  if err != nil {
+    w.WriteHeader(400)
+    w.Write([]byte(err.Error()))
+    return
+  }
+
+  status := query.Get("status")
+  switch status {
+  case "":
+    status = reportOpen
+  case reportOpen, reportClaimed, reportResolved:
+  default:
+    w.WriteHeader(400)
+    w.Write([]byte("status must be open, claimed or resolved"))
+    return
+  }
+
+  // the queue is worked oldest first
+  params := database.GetReportsParams{Status: status, RowLimit: limit + 1}
+  if after := query.Get("after"); after != "" {
+    cursor, err := pagination.DecodeCursor(after)
+    if err != nil {
+      w.WriteHeader(400)
+      w.Write([]byte(err.Error()))
+      return
+    }
+    params.CreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+    params.ID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+  }
+
+  reports, err := cfg.dbQueries.GetReports(r.Context(), params)
+  if err != nil {
+    fmt.Printf("Error retrieving reports: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  page := ReportPage{Reports: []ReadableReport{}}
+  if len(reports) > int(limit) {
+    reports = reports[:limit]
+    last := reports[len(reports)-1]
+    page.NextCursor = pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+  }
+  for _, report := range reports {
+    page.Reports = append(page.Reports, DatabaseReportToReadable(report))
+  }
+
+  resStr, err := json.Marshal(page)
+  if err != nil {
+    fmt.Printf("Error Marshalling reports: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
+
+func (cfg *apiConfig) claimReport(w http.ResponseWriter, r *http.Request) {
+  moderatorID := callerID(r.Context())
+
+  reportID, err := uuid.Parse(r.PathValue("id"))
+  if err != nil {
+    w.WriteHeader(404)
+    return
+  }
+
+  moderator := uuid.NullUUID{UUID: moderatorID, Valid: true}
+  report, err := cfg.dbQueries.ClaimReport(r.Context(), database.ClaimReportParams{ModeratorID: moderator, ID: reportID})
+  if errors.Is(err, sql.ErrNoRows) {
+    // either there's no such report or someone else got to it first
+    if _, err = cfg.dbQueries.GetReport(r.Context(), reportID); errors.Is(err, sql.ErrNoRows) {
+      w.WriteHeader(404)
+      return
+    }
+    w.WriteHeader(409)
+    w.Write([]byte("Report is already claimed or resolved"))
+    return
+  } else if err != nil {
+    fmt.Printf("Error claiming report: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  err = cfg.dbQueries.CreateModerationLogEntry(r.Context(), database.CreateModerationLogEntryParams{
+    ModeratorID: moderator,
+    ReportID: uuid.NullUUID{UUID: report.ID, Valid: true},
+    Action: "claim",
+    TargetUserID: uuid.NullUUID{UUID: report.AuthorID, Valid: true},
+    TargetChirpID: report.ChirpID,
+  })
+  if err != nil {
+    fmt.Printf("Error logging claim: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  resStr, err := json.Marshal(DatabaseReportToReadable(report))
+  if err != nil {
+    fmt.Printf("Error Marshalling report: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
+
+func (cfg *apiConfig) resolveReport(w http.ResponseWriter, r *http.Request) {
+  moderatorID := callerID(r.Context())
+
+  reportID, err := uuid.Parse(r.PathValue("id"))
+  if err != nil {
+    w.WriteHeader(404)
+    return
+  }
+
+  decoder := json.NewDecoder(r.Body)
+  requestBody := ResolveRequest{}
+  if err = decoder.Decode(&requestBody); err != nil {
+    fmt.Printf("Error decoding parameters: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  switch requestBody.Action {
+  case resolutionDismiss, resolutionHideChirp, resolutionDeleteChirp, resolutionWarnUser, resolutionSuspendUser:
+  default:
+    w.WriteHeader(400)
+    w.Write([]byte("Unknown resolution action"))
+    return
+  }
+  if requestBody.SuspendDays < 0 {
+    w.WriteHeader(400)
+    w.Write([]byte("suspend_days cannot be negative"))
+    return
+  }
+
+  report, err := cfg.dbQueries.GetReport(r.Context(), reportID)
+  if errors.Is(err, sql.ErrNoRows) {
+    w.WriteHeader(404)
+    return
+  } else if err != nil {
+    fmt.Printf("Error retrieving report: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  moderator := uuid.NullUUID{UUID: moderatorID, Valid: true}
+  if report.Status != reportClaimed || report.ClaimedBy != moderator {
+    w.WriteHeader(409)
+    w.Write([]byte("Report must be claimed by you before it can be resolved"))
+    return
+  }
+
+  tx, err := cfg.db.BeginTx(r.Context(), nil)
+  if err != nil {
+    fmt.Printf("Error starting transaction: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  defer tx.Rollback()
+  qtx := cfg.dbQueries.WithTx(tx)
+
+  // the action, the resolved report and the log entry land together, so a
+  // failure leaves the report in the queue with nothing done
+  blobs, err := applyResolution(r.Context(), qtx, moderator, report, requestBody)
+  if err != nil {
+    fmt.Printf("Error applying resolution: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  resolution := sql.NullString{String: requestBody.Action, Valid: true}
+  report, err = qtx.ResolveReport(r.Context(), database.ResolveReportParams{ModeratorID: moderator, Resolution: resolution, ID: report.ID})
+  if err != nil {
+    fmt.Printf("Error resolving report: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  // every other report on the chirp was settled by the same decision
+  if report.ChirpID.Valid {
+    if _, err = qtx.ResolveChirpReports(r.Context(), database.ResolveChirpReportsParams{ModeratorID: moderator, Resolution: resolution, ChirpID: report.ChirpID}); err != nil {
+      fmt.Printf("Error resolving chirp reports: %s", err)
+      w.WriteHeader(500)
+      return
+    }
+  }
+
+  err = qtx.CreateModerationLogEntry(r.Context(), database.CreateModerationLogEntryParams{
+    ModeratorID: moderator,
+    ReportID: uuid.NullUUID{UUID: report.ID, Valid: true},
+    Action: requestBody.Action,
+    TargetUserID: uuid.NullUUID{UUID: report.AuthorID, Valid: true},
+    TargetChirpID: report.ChirpID,
+    Note: requestBody.Note,
+  })
+  if err != nil {
+    fmt.Printf("Error logging resolution: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  if err = tx.Commit(); err != nil {
+    fmt.Printf("Error committing resolution: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  cfg.deleteMediaBlobs(r.Context(), blobs)
+
+  resStr, err := json.Marshal(DatabaseReportToReadable(report))
+  if err != nil {
+    fmt.Printf("Error Marshalling report: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
+
+// applyResolution carries out a moderator's decision on the reported chirp
+// or its author through q, the resolving transaction. A held chirp is only
+// released when its report is dismissed. Deleting a chirp returns its media
+// blobs, to be removed after the commit.
+func applyResolution(ctx context.Context, q *database.Queries, moderator uuid.NullUUID, report database.Report, req ResolveRequest) ([]string, error) {
+  var chirp database.Chirp
+  chirpExists := false
+  if report.ChirpID.Valid {
+    var err error
+    chirp, err = q.GetChirp(ctx, report.ChirpID.UUID)
+    if err != nil && !errors.Is(err, sql.ErrNoRows) {
+      return nil, err
+    }
+    chirpExists = err == nil && !chirp.DeletedAt.Valid
+  }
+
+  switch req.Action {
+  case resolutionDismiss:
+    if chirpExists && chirp.ModerationState == chirpHeld {
+      return nil, releaseChirp(ctx, q, chirp)
+    }
+  case resolutionHideChirp:
+    if chirpExists {
+      return nil, q.SetChirpModerationState(ctx, database.SetChirpModerationStateParams{ID: chirp.ID, ModerationState: chirpHidden})
+    }
+  case resolutionDeleteChirp:
+    if chirpExists {
+      return removeChirp(ctx, q, chirp.ID)
+    }
+  case resolutionWarnUser:
+    return nil, q.CreateUserWarning(ctx, database.CreateUserWarningParams{
+      UserID: report.AuthorID,
+      ModeratorID: moderator,
+      ReportID: uuid.NullUUID{UUID: report.ID, Valid: true},
+      Note: req.Note,
+    })
+  case resolutionSuspendUser:
+    _, err := suspendUser(ctx, q, report.AuthorID, suspensionEnd(req.SuspendDays), req.Note, req.HideChirps)
+    return nil, err
+  }
+  return nil, nil
+}
+
+// releaseChirp makes a held chirp public and indexes it, which was skipped
+// while it waited for review.
+func releaseChirp(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+  if err := q.SetChirpModerationState(ctx, database.SetChirpModerationStateParams{ID: chirp.ID, ModerationState: chirpVisible}); err != nil {
+    return err
+  }
+  chirp.ModerationState = chirpVisible
+  if err := saveTags(ctx, q, chirp); err != nil {
+    return err
+  }
+  return saveMentions(ctx, q, chirp)
+}
+
+func (cfg *apiConfig) getModerationLog(w http.ResponseWriter, r *http.Request) {
+  query := r.URL.Query()
+  limit, err := pagination.ParseLimit(query.Get("limit"))
+  if err != nil {
+    w.WriteHeader(400)
+    w.Write([]byte(err.Error()))
+    return
+  }
+
+  params := database.GetModerationLogParams{RowLimit: limit + 1}
+  if after := query.Get("after"); after != "" {
+    cursor, err := pagination.DecodeCursor(after)
+    if err != nil {
+      w.WriteHeader(400)
+      w.Write([]byte(err.Error()))
+      return
+    }
+    params.CreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+    params.ID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+  }
+
+  entries, err := cfg.dbQueries.GetModerationLog(r.Context(), params)
+  if err != nil {
+    fmt.Printf("Error retrieving moderation log: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  page := ModerationLogPage{Entries: []ModerationLogEntry{}}
+  if len(entries) > int(limit) {
+    entries = entries[:limit]
+    last := entries[len(entries)-1]
+    page.NextCursor = pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+  }
+  for _, entry := range entries {
+    page.Entries = append(page.Entries, ModerationLogEntry(entry))
+  }
+
+  resStr, err := json.Marshal(page)
+  if err != nil {
+    fmt.Printf("Error Marshalling moderation log: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
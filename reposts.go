@@ -0,0 +1,148 @@
+package main
+
+import (
+  "context"
+  "database/sql"
+  "errors"
+
+  "github.com/google/uuid"
+
+  "github.com/j-wut/chirpy/internal/database"
+)
+
+const (
+  chirpKindChirp    = "chirp"
+  chirpKindRechirp  = "rechirp"
+  chirpKindQuote    = "quote"
+)
+
+var errAlreadyRechirped = errors.New("Chirp has already been rechirped")
+
+// createRepost rechirps originalID, or quotes it when body is not empty. The
+// repost is written through q so it can join the caller's transaction.
+func (cfg *apiConfig) createRepost(ctx context.Context, q *database.Queries, userID, originalID uuid.UUID, body, state string) (database.Chirp, error) {
+  original, err := cfg.dbQueries.GetChirp(ctx, originalID)
+  if err != nil {
+    return database.Chirp{}, err
+  }
+  viewer := uuid.NullUUID{UUID: userID, Valid: true}
+  if !chirpVisibleTo(original, viewer) {
+    return database.Chirp{}, sql.ErrNoRows
+  }
+  if visible, err := cfg.authorVisibleTo(ctx, original.UserID, viewer); err != nil {
+    return database.Chirp{}, err
+  } else if !visible {
+    return database.Chirp{}, sql.ErrNoRows
+  }
+  if blocked, err := cfg.blockedEitherWay(ctx, userID, original.UserID); err != nil {
+    return database.Chirp{}, err
+  } else if blocked {
+    return database.Chirp{}, errBlocked
+  }
+
+  // reposting a rechirp reposts what it points at, so there is only ever one
+  // level of original to embed
+  if original.Kind == chirpKindRechirp && original.RepostOf.Valid {
+    originalID = original.RepostOf.UUID
+  }
+
+  kind := chirpKindQuote
+  if body == "" {
+    kind = chirpKindRechirp
+  }
+
+  chirp, err := q.CreateRepost(ctx, database.CreateRepostParams{Body: body, UserID: userID, Kind: kind, ModerationState: state, RepostOf: originalID})
+  if isUniqueViolation(err) {
+    return database.Chirp{}, errAlreadyRechirped
+  }
+  return chirp, err
+}
+
+// addOriginals embeds the chirp each repost points at. Reposts whose
+// original has been deleted, or that viewer isn't allowed to see because of a
+// block or a protected account, are flagged instead.
+func (cfg *apiConfig) addOriginals(ctx context.Context, viewer uuid.NullUUID, chirps []ReadableChirp) error {
+  ids := []uuid.UUID{}
+  for _, chirp := range chirps {
+    if chirp.RepostOf.Valid && !chirp.Deleted {
+      ids = append(ids, chirp.RepostOf.UUID)
+    }
+  }
+  if len(ids) == 0 {
+    return nil
+  }
+
+  found, err := cfg.dbQueries.GetChirpsByIDs(ctx, ids)
+  if err != nil {
+    return err
+  }
+  originals := DatabaseChirpsToReadable(found)
+  if err = cfg.addMentions(ctx, originals); err != nil {
+    return err
+  }
+  if err = cfg.addLikes(ctx, viewer, originals); err != nil {
+    return err
+  }
+  if err = cfg.addMedia(ctx, originals); err != nil {
+    return err
+  }
+
+  blocked, err := cfg.blockedUsers(ctx, viewer)
+  if err != nil {
+    return err
+  }
+  authorIDs := []uuid.UUID{}
+  for _, original := range originals {
+    authorIDs = append(authorIDs, original.UserID)
+  }
+  hidden, err := cfg.hiddenAuthors(ctx, viewer, authorIDs)
+  if err != nil {
+    return err
+  }
+
+  byID := map[uuid.UUID]*ReadableChirp{}
+  for i := range originals {
+    if !originals[i].Deleted && !originals[i].Held && !blocked[originals[i].UserID] && !hidden[originals[i].UserID] {
+      byID[originals[i].ID] = &originals[i]
+    }
+  }
+
+  for i := range chirps {
+    if !chirps[i].RepostOf.Valid || chirps[i].Deleted {
+      continue
+    }
+    if original, ok := byID[chirps[i].RepostOf.UUID]; ok {
+      chirps[i].Original = original
+    } else {
+      chirps[i].OriginalDeleted = true
+    }
+  }
+  return nil
+}
+
+// liveChirpIDs lists the chirps worth decorating. Tombstones are skipped, so
+// nothing that belonged to a deleted or hidden chirp finds its way back.
+func liveChirpIDs(chirps []ReadableChirp) []uuid.UUID {
+  ids := make([]uuid.UUID, 0, len(chirps))
+  for _, chirp := range chirps {
+    if !chirp.Deleted {
+      ids = append(ids, chirp.ID)
+    }
+  }
+  return ids
+}
+
+// decorateChirps fills in everything a chirp response carries beyond its own
+// row, from the point of view of viewer.
+func (cfg *apiConfig) decorateChirps(ctx context.Context, viewer uuid.NullUUID, chirps []ReadableChirp) error {
+  if err := cfg.addOriginals(ctx, viewer, chirps); err != nil {
+    return err
+  }
+  if err := cfg.addMentions(ctx, chirps); err != nil {
+    return err
+  }
+  if err := cfg.addLikes(ctx, viewer, chirps); err != nil {
+    return err
+  }
+  return cfg.addMedia(ctx, chirps)
+}
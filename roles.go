@@ -0,0 +1,145 @@
+package main
+
+import (
+  "context"
+  "database/sql"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "net/http"
+
+  "github.com/google/uuid"
+
+  "github.com/j-wut/chirpy/internal/auth"
+  "github.com/j-wut/chirpy/internal/database"
+)
+
+type contextKey string
+
+const callerIDKey contextKey = "callerID"
+
+// middlewareRequireRole only lets through callers whose JWT carries at
+// least role, and hands their ID on through the request context. API keys
+// never get through, since they can't hold the account scope.
+func (cfg *apiConfig) middlewareRequireRole(role string, next http.Handler) http.Handler {
+  return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    userID, userRole, ok := cfg.authenticateRole(w, r, auth.ScopeAccount)
+    if !ok {
+      return
+    }
+
+    if !auth.HasRole(userRole, role) {
+      w.WriteHeader(403)
+      return
+    }
+
+    next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), callerIDKey, userID)))
+  })
+}
+
+// callerID is the authenticated user behind a request that went through
+// middlewareRequireRole.
+func callerID(ctx context.Context) uuid.UUID {
+  userID, _ := ctx.Value(callerIDKey).(uuid.UUID)
+  return userID
+}
+
+type RoleRequest struct {
+  Role string `json:"role"`
+}
+
+func (cfg *apiConfig) setUserRole(w http.ResponseWriter, r *http.Request) {
+  adminID := callerID(r.Context())
+
+  userID, err := uuid.Parse(r.PathValue("id"))
+  if err != nil {
+    w.WriteHeader(404)
+    return
+  }
+
+  decoder := json.NewDecoder(r.Body)
+  requestBody := RoleRequest{}
+  if err = decoder.Decode(&requestBody); err != nil {
+    fmt.Printf("Error decoding parameters: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  if !auth.ValidRole(requestBody.Role) {
+    w.WriteHeader(400)
+    w.Write([]byte("role must be user, moderator or admin"))
+    return
+  }
+
+  tx, err := cfg.db.BeginTx(r.Context(), nil)
+  if err != nil {
+    fmt.Printf("Error starting transaction: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  defer tx.Rollback()
+  qtx := cfg.dbQueries.WithTx(tx)
+
+  // lock every admin before reading the user, so two admins demoting each
+  // other can't both see the other one still holding the role
+  admins, err := qtx.LockUsersWithRole(r.Context(), auth.RoleAdmin)
+  if err != nil {
+    fmt.Printf("Error locking admins: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  user, err := qtx.GetUserByID(r.Context(), userID)
+  if errors.Is(err, sql.ErrNoRows) {
+    w.WriteHeader(404)
+    return
+  } else if err != nil {
+    fmt.Printf("Error retrieving user: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  // never leave the site without an admin to hand the role back out
+  if user.Role == auth.RoleAdmin && requestBody.Role != auth.RoleAdmin && len(admins) <= 1 {
+    w.WriteHeader(409)
+    w.Write([]byte("Cannot demote the last admin"))
+    return
+  }
+
+  user, err = qtx.SetUserRole(r.Context(), database.SetUserRoleParams{ID: user.ID, Role: requestBody.Role})
+  if err != nil {
+    fmt.Printf("Error setting role: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  err = qtx.CreateModerationLogEntry(r.Context(), database.CreateModerationLogEntryParams{
+    ModeratorID: uuid.NullUUID{UUID: adminID, Valid: true},
+    Action: "set_role",
+    TargetUserID: uuid.NullUUID{UUID: user.ID, Valid: true},
+    Note: requestBody.Role,
+  })
+  if err != nil {
+    fmt.Printf("Error logging role change: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  if err = tx.Commit(); err != nil {
+    fmt.Printf("Error committing role change: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  resStr, err := json.Marshal(DatabaseUserToReadable(user))
+  if err != nil {
+    fmt.Printf("Error Marshalling user: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+  "database/sql"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "net/http"
+
+  "github.com/google/uuid"
+
+  "github.com/j-wut/chirpy/internal/database"
+  "github.com/j-wut/chirpy/internal/pagination"
+  "github.com/j-wut/chirpy/internal/search"
+)
+
+// searchChirps ranks chirps against q, which supports "phrases", -excluded
+// words and the from:, since: and until: operators.
+func (cfg *apiConfig) searchChirps(w http.ResponseWriter, r *http.Request) {
+  query := r.URL.Query()
+
+  parsed, err := search.Parse(query.Get("q"))
+  if err != nil {
+    w.WriteHeader(400)
+    w.Write([]byte(err.Error()))
+    return
+  }
+  if parsed.Text == "" && parsed.From == "" && parsed.Since == nil && parsed.Until == nil {
+    w.WriteHeader(400)
+    w.Write([]byte("q is required"))
+    return
+  }
+
+  limit, err := pagination.ParseLimit(query.Get("limit"))
+  if err != nil {
+    w.WriteHeader(400)
+    w.Write([]byte(err.Error()))
+    return
+  }
+
+  viewer := cfg.optionalUserID(r)
+  params := database.SearchChirpsParams{Query: parsed.Text, ViewerID: viewer, RowLimit: limit + 1}
+  if after := query.Get("after"); after != "" {
+    params.RowOffset, err = pagination.DecodeOffset(after)
+    if err != nil {
+      w.WriteHeader(400)
+      w.Write([]byte(err.Error()))
+      return
+    }
+  }
+  if parsed.Since != nil {
+    params.Since = sql.NullTime{Time: *parsed.Since, Valid: true}
+  }
+  if parsed.Until != nil {
+    params.Until = sql.NullTime{Time: *parsed.Until, Valid: true}
+  }
+
+  page := ChirpPage{Chirps: []ReadableChirp{}}
+  if parsed.From != "" {
+    author, err := cfg.dbQueries.GetUserByUsername(r.Context(), parsed.From)
+    if errors.Is(err, sql.ErrNoRows) {
+      // nobody by that name, so nothing can match
+      writeChirpPage(w, page)
+      return
+    } else if err != nil {
+      fmt.Printf("Error retrieving user: %s", err)
+      w.WriteHeader(500)
+      return
+    }
+    params.AuthorID = uuid.NullUUID{UUID: author.ID, Valid: true}
+  }
+
+  chirps, err := cfg.dbQueries.SearchChirps(r.Context(), params)
+  if err != nil {
+    fmt.Printf("Error searching chirps: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  if len(chirps) > int(limit) {
+    chirps = chirps[:limit]
+    page.NextCursor = pagination.EncodeOffset(params.RowOffset + limit)
+  }
+  page.Chirps = DatabaseChirpsToReadable(chirps)
+
+  if err = cfg.decorateChirps(r.Context(), viewer, page.Chirps); err != nil {
+    fmt.Printf("Error decorating chirps: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  writeChirpPage(w, page)
+}
+
+func writeChirpPage(w http.ResponseWriter, page ChirpPage) {
+  resStr, err := json.Marshal(page)
+  if err != nil {
+    fmt.Printf("Error Marshalling chirps: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+}
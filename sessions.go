@@ -0,0 +1,156 @@
+package main
+
+import (
+  "encoding/json"
+  "fmt"
+  "net/http"
+  "time"
+  "unicode/utf8"
+
+  "github.com/google/uuid"
+
+  "github.com/j-wut/chirpy/internal/auth"
+  "github.com/j-wut/chirpy/internal/database"
+)
+
+const maxSessionNameLength = 50
+
+// Session is one login, identified by the family its refresh tokens share.
+type Session struct {
+  ID          uuid.UUID `json:"id"`
+  Name        string    `json:"name"`
+  StartedAt   time.Time `json:"started_at"`
+  LastUsedAt  time.Time `json:"last_used_at"`
+  ExpiresAt   time.Time `json:"expires_at"`
+  UserAgent   string    `json:"user_agent"`
+  IPAddress   string    `json:"ip_address"`
+}
+
+type SessionRequest struct {
+  Name string `json:"name"`
+}
+
+func (cfg *apiConfig) getSessions(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeAccount)
+  if !ok {
+    return
+  }
+
+  rows, err := cfg.dbQueries.GetSessions(r.Context(), userID)
+  if err != nil {
+    fmt.Printf("Error retrieving sessions: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  sessions := []Session{}
+  for _, s := range rows {
+    sessions = append(sessions, Session{
+      ID: s.FamilyID,
+      Name: s.SessionName,
+      StartedAt: s.StartedAt,
+      LastUsedAt: s.LastUsedAt,
+      ExpiresAt: s.ExpiresAt,
+      UserAgent: s.UserAgent,
+      IPAddress: s.IpAddress,
+    })
+  }
+
+  resStr, err := json.Marshal(sessions)
+  if err != nil {
+    fmt.Printf("Error Marshalling sessions: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
+
+func (cfg *apiConfig) renameSession(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeAccount)
+  if !ok {
+    return
+  }
+
+  sessionID, err := uuid.Parse(r.PathValue("id"))
+  if err != nil {
+    w.WriteHeader(404)
+    return
+  }
+
+  decoder := json.NewDecoder(r.Body)
+  requestBody := SessionRequest{}
+  if err = decoder.Decode(&requestBody); err != nil {
+    fmt.Printf("Error decoding parameters: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  if utf8.RuneCountInString(requestBody.Name) > maxSessionNameLength {
+    w.WriteHeader(400)
+    w.Write([]byte(fmt.Sprintf("Session name can be at most %d characters", maxSessionNameLength)))
+    return
+  }
+
+  renamed, err := cfg.dbQueries.RenameSession(r.Context(), database.RenameSessionParams{FamilyID: sessionID, UserID: userID, SessionName: requestBody.Name})
+  if err != nil {
+    fmt.Printf("Error renaming session: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  if renamed == 0 {
+    w.WriteHeader(404)
+    return
+  }
+
+  w.WriteHeader(204)
+  return
+}
+
+func (cfg *apiConfig) revokeSession(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeAccount)
+  if !ok {
+    return
+  }
+
+  sessionID, err := uuid.Parse(r.PathValue("id"))
+  if err != nil {
+    w.WriteHeader(404)
+    return
+  }
+
+  revoked, err := cfg.dbQueries.RevokeSession(r.Context(), database.RevokeSessionParams{FamilyID: sessionID, UserID: userID})
+  if err != nil {
+    fmt.Printf("Error revoking session: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  if revoked == 0 {
+    w.WriteHeader(404)
+    return
+  }
+
+  w.WriteHeader(204)
+  return
+}
+
+// revokeAllSessions logs the caller out everywhere. Access tokens already
+// handed out stay valid until they expire.
+func (cfg *apiConfig) revokeAllSessions(w http.ResponseWriter, r *http.Request) {
+  userID, ok := cfg.authenticate(w, r, auth.ScopeAccount)
+  if !ok {
+    return
+  }
+
+  if err := cfg.dbQueries.RevokeUserRefreshTokens(r.Context(), userID); err != nil {
+    fmt.Printf("Error revoking sessions: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.WriteHeader(204)
+  return
+}
@@ -0,0 +1,21 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (id, user_id, name, prefix, hashed_key, scopes, created_at, expires_at)
+VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, now(), $6)
+RETURNING *;
+
+-- name: GetAPIKeyByHash :one
+SELECT * FROM api_keys
+WHERE hashed_key = $1 AND revoked_at IS NULL;
+
+-- name: GetAPIKeys :many
+SELECT * FROM api_keys
+WHERE user_id = $1 AND revoked_at IS NULL
+ORDER BY created_at DESC;
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys SET revoked_at = now()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys SET last_used_at = now()
+WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute');
@@ -0,0 +1,47 @@
+-- name: BlockUser :exec
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES ($1, $2, now())
+ON CONFLICT DO NOTHING;
+
+-- name: UnblockUser :execrows
+DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: GetBlocks :many
+SELECT * FROM blocks WHERE blocker_id = $1 ORDER BY created_at DESC;
+
+-- name: IsBlockedEitherWay :one
+SELECT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocker_id = sqlc.arg(user_a) AND blocked_id = sqlc.arg(user_b))
+       OR (blocker_id = sqlc.arg(user_b) AND blocked_id = sqlc.arg(user_a))
+);
+
+-- name: GetBlockedEitherWay :many
+SELECT blocked_id AS user_id FROM blocks WHERE blocker_id = sqlc.arg(user_id)
+UNION
+SELECT blocker_id AS user_id FROM blocks WHERE blocked_id = sqlc.arg(user_id);
+
+-- name: MuteUser :exec
+INSERT INTO mutes (muter_id, muted_id, created_at)
+VALUES ($1, $2, now())
+ON CONFLICT DO NOTHING;
+
+-- name: UnmuteUser :execrows
+DELETE FROM mutes WHERE muter_id = $1 AND muted_id = $2;
+
+-- name: GetMutes :many
+SELECT * FROM mutes WHERE muter_id = $1 ORDER BY created_at DESC;
+
+-- name: AddMutedKeyword :exec
+INSERT INTO muted_keywords (user_id, keyword, created_at)
+VALUES ($1, $2, now())
+ON CONFLICT DO NOTHING;
+
+-- name: KeywordCanMatch :one
+SELECT numnode(phraseto_tsquery('english', sqlc.arg(keyword)::text)) > 0 AS can_match;
+
+-- name: RemoveMutedKeyword :execrows
+DELETE FROM muted_keywords WHERE user_id = $1 AND keyword = $2;
+
+-- name: GetMutedKeywords :many
+SELECT * FROM muted_keywords WHERE user_id = $1 ORDER BY keyword;
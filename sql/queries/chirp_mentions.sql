@@ -0,0 +1,32 @@
+-- name: CreateChirpMention :exec
+INSERT INTO chirp_mentions (chirp_id, user_id, start_offset, end_offset)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM chirp_mentions WHERE chirp_id = $1;
+
+-- name: GetChirpMentions :many
+SELECT chirp_mentions.chirp_id, chirp_mentions.user_id, users.username, chirp_mentions.start_offset, chirp_mentions.end_offset
+FROM chirp_mentions
+JOIN users ON users.id = chirp_mentions.user_id
+WHERE chirp_mentions.chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY chirp_mentions.chirp_id, chirp_mentions.start_offset;
+
+-- name: GetMentionChirps :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+  AND moderation_state = 'visible'
+  AND user_id NOT IN (SELECT id FROM users WHERE suspension_hides_chirps AND suspended_at IS NOT NULL AND (suspended_until IS NULL OR suspended_until > now()))
+  AND id IN (SELECT chirp_id FROM chirp_mentions WHERE chirp_mentions.user_id = sqlc.arg(user_id))
+  AND user_id NOT IN (SELECT blocked_id FROM blocks WHERE blocker_id = sqlc.arg(user_id) UNION SELECT blocker_id FROM blocks WHERE blocked_id = sqlc.arg(user_id))
+  AND user_id NOT IN (SELECT muted_id FROM mutes WHERE muter_id = sqlc.arg(user_id))
+  AND (user_id NOT IN (SELECT id FROM users WHERE protected) OR user_id IN (SELECT followee_id FROM follows WHERE follower_id = sqlc.arg(user_id)))
+  AND (sqlc.narg(created_at)::timestamp IS NULL OR (created_at, id) < (sqlc.narg(created_at), sqlc.narg(id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
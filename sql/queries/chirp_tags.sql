@@ -0,0 +1,36 @@
+-- name: CreateChirpTags :exec
+INSERT INTO chirp_tags (chirp_id, tag, created_at)
+SELECT id, unnest(sqlc.arg(tags)::text[]), created_at
+FROM chirps WHERE id = sqlc.arg(chirp_id)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpTags :exec
+DELETE FROM chirp_tags WHERE chirp_id = $1;
+
+-- name: GetTagChirps :many
+SELECT chirps.* FROM chirps
+JOIN chirp_tags ON chirp_tags.chirp_id = chirps.id
+WHERE chirp_tags.tag = sqlc.arg(tag)
+  AND chirps.deleted_at IS NULL
+  AND chirps.moderation_state = 'visible'
+  AND chirps.user_id NOT IN (SELECT id FROM users WHERE suspension_hides_chirps AND suspended_at IS NOT NULL AND (suspended_until IS NULL OR suspended_until > now()))
+  AND (sqlc.narg(viewer_id)::uuid IS NULL OR chirps.user_id NOT IN (SELECT blocked_id FROM blocks WHERE blocker_id = sqlc.narg(viewer_id) UNION SELECT blocker_id FROM blocks WHERE blocked_id = sqlc.narg(viewer_id)))
+  AND (chirps.user_id NOT IN (SELECT id FROM users WHERE protected) OR chirps.user_id = sqlc.narg(viewer_id) OR chirps.user_id IN (SELECT followee_id FROM follows WHERE follower_id = sqlc.narg(viewer_id)))
+  AND (sqlc.narg(created_at)::timestamp IS NULL OR (chirps.created_at, chirps.id) < (sqlc.narg(created_at), sqlc.narg(id)::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetTrendingTags :many
+SELECT tag, recent_count, previous_count,
+    (recent_count - previous_count)::float8 / (previous_count + 1) AS growth
+FROM (
+    SELECT tag,
+        count(*) FILTER (WHERE created_at >= now() - sqlc.arg(window_seconds)::int * interval '1 second') AS recent_count,
+        count(*) FILTER (WHERE created_at < now() - sqlc.arg(window_seconds)::int * interval '1 second') AS previous_count
+    FROM chirp_tags
+    WHERE created_at >= now() - 2 * sqlc.arg(window_seconds)::int * interval '1 second'
+    GROUP BY tag
+) AS counts
+WHERE recent_count > 0
+ORDER BY growth DESC, recent_count DESC, tag
+LIMIT sqlc.arg(row_limit);
@@ -1,22 +1,100 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
-VALUES (
-    gen_random_uuid(),
-    now(),
-    now(),
-    $1,
-    $2
-)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, conversation_id, moderation_state)
+SELECT new_chirp.id, now(), now(), sqlc.arg(body)::text, sqlc.arg(user_id)::uuid, new_chirp.id, sqlc.arg(moderation_state)::text
+FROM (SELECT gen_random_uuid() AS id) AS new_chirp
+RETURNING *;
+
+-- name: CreateReply :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to, conversation_id, moderation_state)
+SELECT gen_random_uuid(), now(), now(), sqlc.arg(body)::text, sqlc.arg(user_id)::uuid, parent.id, parent.conversation_id, sqlc.arg(moderation_state)::text
+FROM chirps AS parent
+WHERE parent.id = sqlc.arg(in_reply_to) AND parent.deleted_at IS NULL
+  AND (parent.moderation_state = 'visible' OR parent.user_id = sqlc.arg(user_id))
+RETURNING *;
+
+-- name: CreateRepost :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, conversation_id, repost_of, kind, moderation_state)
+SELECT new_chirp.id, now(), now(), sqlc.arg(body)::text, sqlc.arg(user_id)::uuid, new_chirp.id, original.id, sqlc.arg(kind)::text, sqlc.arg(moderation_state)::text
+FROM (SELECT gen_random_uuid() AS id) AS new_chirp, chirps AS original
+WHERE original.id = sqlc.arg(repost_of) AND original.deleted_at IS NULL
 RETURNING *;
 
 -- name: GetAllChirps :many
-SELECT * FROM chirps ORDER BY created_at;
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+  AND moderation_state = 'visible'
+  AND user_id NOT IN (SELECT id FROM users WHERE suspension_hides_chirps AND suspended_at IS NOT NULL AND (suspended_until IS NULL OR suspended_until > now()))
+ORDER BY created_at;
+
+-- name: GetChirpsAfter :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+  AND moderation_state = 'visible'
+  AND user_id NOT IN (SELECT id FROM users WHERE suspension_hides_chirps AND suspended_at IS NOT NULL AND (suspended_until IS NULL OR suspended_until > now()))
+  AND (sqlc.narg(author_id)::uuid IS NULL OR user_id = sqlc.narg(author_id))
+  AND (sqlc.narg(viewer_id)::uuid IS NULL OR user_id NOT IN (SELECT blocked_id FROM blocks WHERE blocker_id = sqlc.narg(viewer_id) UNION SELECT blocker_id FROM blocks WHERE blocked_id = sqlc.narg(viewer_id)))
+  AND (user_id NOT IN (SELECT id FROM users WHERE protected) OR user_id = sqlc.narg(viewer_id) OR user_id IN (SELECT followee_id FROM follows WHERE follower_id = sqlc.narg(viewer_id)))
+  AND (sqlc.narg(created_at)::timestamp IS NULL OR (created_at, id) > (sqlc.narg(created_at), sqlc.narg(id)::uuid))
+ORDER BY created_at, id
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetChirpsBefore :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+  AND moderation_state = 'visible'
+  AND user_id NOT IN (SELECT id FROM users WHERE suspension_hides_chirps AND suspended_at IS NOT NULL AND (suspended_until IS NULL OR suspended_until > now()))
+  AND (sqlc.narg(author_id)::uuid IS NULL OR user_id = sqlc.narg(author_id))
+  AND (sqlc.narg(viewer_id)::uuid IS NULL OR user_id NOT IN (SELECT blocked_id FROM blocks WHERE blocker_id = sqlc.narg(viewer_id) UNION SELECT blocker_id FROM blocks WHERE blocked_id = sqlc.narg(viewer_id)))
+  AND (user_id NOT IN (SELECT id FROM users WHERE protected) OR user_id = sqlc.narg(viewer_id) OR user_id IN (SELECT followee_id FROM follows WHERE follower_id = sqlc.narg(viewer_id)))
+  AND (sqlc.narg(created_at)::timestamp IS NULL OR (created_at, id) < (sqlc.narg(created_at), sqlc.narg(id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
 
 -- name: GetChirp :one
 SELECT * FROM chirps WHERE id = $1;
 
+-- name: GetChirpForUpdate :one
+SELECT * FROM chirps WHERE id = $1
+FOR UPDATE;
+
+-- name: GetChirpsByIDs :many
+SELECT * FROM chirps WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: GetConversation :many
+SELECT * FROM chirps WHERE conversation_id = $1 ORDER BY created_at, id;
+
+-- name: HasReplies :one
+SELECT EXISTS (SELECT 1 FROM chirps WHERE in_reply_to = $1);
+
 -- name: DeleteChirp :exec
 DELETE FROM chirps WHERE id = $1;
 
+-- name: DeleteRechirps :exec
+DELETE FROM chirps WHERE repost_of = $1 AND kind = 'rechirp';
+
+-- name: TombstoneChirp :exec
+UPDATE chirps SET body = '', updated_at = now(), deleted_at = now() WHERE id = $1;
+
 -- name: ResetChirps :exec
 DELETE FROM chirps;
+
+-- name: UpdateChirpBody :one
+UPDATE chirps SET body = $2, updated_at = now() WHERE id = $1
+RETURNING *;
+
+-- name: SetChirpModerationState :exec
+UPDATE chirps SET moderation_state = $2 WHERE id = $1;
+
+-- name: SearchChirps :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+  AND moderation_state = 'visible'
+  AND user_id NOT IN (SELECT id FROM users WHERE suspension_hides_chirps AND suspended_at IS NOT NULL AND (suspended_until IS NULL OR suspended_until > now()))
+  AND (sqlc.arg(query)::text = '' OR search_vector @@ websearch_to_tsquery('english', sqlc.arg(query)))
+  AND (sqlc.narg(author_id)::uuid IS NULL OR user_id = sqlc.narg(author_id))
+  AND (sqlc.narg(viewer_id)::uuid IS NULL OR user_id NOT IN (SELECT blocked_id FROM blocks WHERE blocker_id = sqlc.narg(viewer_id) UNION SELECT blocker_id FROM blocks WHERE blocked_id = sqlc.narg(viewer_id)))
+  AND (user_id NOT IN (SELECT id FROM users WHERE protected) OR user_id = sqlc.narg(viewer_id) OR user_id IN (SELECT followee_id FROM follows WHERE follower_id = sqlc.narg(viewer_id)))
+  AND (sqlc.narg(since)::timestamp IS NULL OR created_at >= sqlc.narg(since))
+  AND (sqlc.narg(until)::timestamp IS NULL OR created_at < sqlc.narg(until))
+ORDER BY ts_rank(search_vector, websearch_to_tsquery('english', sqlc.arg(query))) DESC, created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
@@ -0,0 +1,31 @@
+-- name: CreateFollowRequest :exec
+INSERT INTO follow_requests (requester_id, target_id, created_at)
+VALUES ($1, $2, now())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteFollowRequest :execrows
+DELETE FROM follow_requests WHERE requester_id = $1 AND target_id = $2;
+
+-- name: GetFollowRequests :many
+SELECT * FROM follow_requests WHERE target_id = $1 ORDER BY created_at DESC;
+
+-- name: DeleteFollowRequestsBetween :exec
+DELETE FROM follow_requests
+WHERE (requester_id = sqlc.arg(user_a) AND target_id = sqlc.arg(user_b))
+   OR (requester_id = sqlc.arg(user_b) AND target_id = sqlc.arg(user_a));
+
+-- name: ApproveAllFollowRequests :exec
+WITH approved AS (
+    DELETE FROM follow_requests WHERE target_id = $1
+    RETURNING requester_id, target_id
+)
+INSERT INTO follows (follower_id, followee_id, created_at)
+SELECT requester_id, target_id, now() FROM approved
+ON CONFLICT DO NOTHING;
+
+-- name: GetHiddenAuthors :many
+SELECT id FROM users
+WHERE id = ANY(sqlc.arg(user_ids)::uuid[])
+  AND protected
+  AND id IS DISTINCT FROM sqlc.narg(viewer_id)::uuid
+  AND id NOT IN (SELECT followee_id FROM follows WHERE follower_id = sqlc.narg(viewer_id));
@@ -0,0 +1,39 @@
+-- name: CreateFollow :exec
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES (
+    $1,
+    $2,
+    now()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteFollow :exec
+DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: GetFollowers :many
+SELECT * FROM follows WHERE followee_id = $1 ORDER BY created_at DESC;
+
+-- name: GetFollowing :many
+SELECT * FROM follows WHERE follower_id = $1 ORDER BY created_at DESC;
+
+-- name: GetTimelineChirps :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+  AND moderation_state = 'visible'
+  AND user_id NOT IN (SELECT id FROM users WHERE suspension_hides_chirps AND suspended_at IS NOT NULL AND (suspended_until IS NULL OR suspended_until > now()))
+  AND user_id IN (SELECT followee_id FROM follows WHERE follower_id = sqlc.arg(user_id))
+  AND user_id NOT IN (SELECT blocked_id FROM blocks WHERE blocker_id = sqlc.arg(user_id) UNION SELECT blocker_id FROM blocks WHERE blocked_id = sqlc.arg(user_id))
+  AND user_id NOT IN (SELECT muted_id FROM mutes WHERE muter_id = sqlc.arg(user_id))
+  AND NOT EXISTS (
+    SELECT 1 FROM muted_keywords
+    WHERE muted_keywords.user_id = sqlc.arg(user_id)
+      AND chirps.search_vector @@ phraseto_tsquery('english', muted_keywords.keyword)
+  )
+  AND (sqlc.narg(created_at)::timestamp IS NULL OR (created_at, id) < (sqlc.narg(created_at), sqlc.narg(id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: DeleteFollowsBetween :exec
+DELETE FROM follows
+WHERE (follower_id = sqlc.arg(user_a) AND followee_id = sqlc.arg(user_b))
+   OR (follower_id = sqlc.arg(user_b) AND followee_id = sqlc.arg(user_a));
@@ -0,0 +1,62 @@
+-- name: CreateMedia :one
+INSERT INTO media (id, created_at, user_id, content_type, size_bytes, storage_key, width, height, blurhash)
+VALUES (
+    $1,
+    now(),
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+RETURNING *;
+
+-- name: CreateMediaVariant :one
+INSERT INTO media_variants (media_id, name, content_type, size_bytes, storage_key, width, height)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+RETURNING *;
+
+-- name: GetStoredMedia :one
+SELECT content_type, user_id, chirp_id FROM media WHERE storage_key = sqlc.arg(storage_key)
+UNION ALL
+SELECT media_variants.content_type, media.user_id, media.chirp_id FROM media_variants
+JOIN media ON media.id = media_variants.media_id
+WHERE media_variants.storage_key = sqlc.arg(storage_key)
+LIMIT 1;
+
+-- name: GetUnattachedMedia :many
+SELECT * FROM media
+WHERE id = ANY(sqlc.arg(ids)::uuid[]) AND user_id = sqlc.arg(user_id) AND chirp_id IS NULL;
+
+-- name: AttachMedia :execrows
+UPDATE media SET chirp_id = $1, position = $2
+WHERE id = $3 AND user_id = $4 AND chirp_id IS NULL;
+
+-- name: GetChirpMedia :many
+SELECT * FROM media
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: GetMediaVariants :many
+SELECT * FROM media_variants
+WHERE media_id = ANY(sqlc.arg(media_ids)::uuid[])
+ORDER BY media_id, width;
+
+-- name: DeleteChirpMediaVariants :many
+DELETE FROM media_variants
+WHERE media_id IN (SELECT id FROM media WHERE chirp_id = $1)
+RETURNING storage_key;
+
+-- name: DeleteChirpMedia :many
+DELETE FROM media WHERE chirp_id = $1
+RETURNING storage_key;
@@ -0,0 +1,2 @@
+-- name: GetModerationRules :many
+SELECT * FROM moderation_rules ORDER BY created_at, id;
@@ -1,22 +1,50 @@
 -- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, expires_at, user_id)
+INSERT INTO refresh_tokens (token, created_at, updated_at, expires_at, user_id, family_id, user_agent, ip_address, last_used_at, session_name)
 VALUES (
     $1,
     now(),
     now(),
     $2,
-    $3
+    $3,
+    $4,
+    $5,
+    $6,
+    now(),
+    $7
 )
 RETURNING *;
 
 -- name: GetRefreshToken :one
-SELECT * FROM refresh_tokens WHERE token = $1 AND revoked_at IS NULL;
+SELECT * FROM refresh_tokens WHERE token = $1;
+
+-- name: GetSessions :many
+SELECT family_id,
+    (SELECT min(created_at) FROM refresh_tokens AS family WHERE family.family_id = refresh_tokens.family_id)::timestamp AS started_at,
+    last_used_at, user_agent, ip_address, session_name, expires_at
+FROM refresh_tokens
+WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > now()
+ORDER BY last_used_at DESC;
+
+-- name: RenameSession :execrows
+UPDATE refresh_tokens SET session_name = $3, updated_at = now()
+WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL;
 
--- name: GetRefreshTokenFromUserID :one
-SELECT * FROM refresh_tokens WHERE user_id = $1 AND revoked_at IS NULL;
+-- name: RevokeSession :execrows
+UPDATE refresh_tokens SET revoked_at = now(), updated_at = now()
+WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL;
 
 -- name: RevokeRefreshToken :exec
 UPDATE refresh_tokens SET revoked_at = now() WHERE token = $1;
 
+-- name: RotateRefreshToken :execrows
+UPDATE refresh_tokens SET revoked_at = now(), updated_at = now(), replaced_by = $2
+WHERE token = $1 AND revoked_at IS NULL;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens SET revoked_at = now(), updated_at = now() WHERE family_id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens SET revoked_at = now(), updated_at = now() WHERE user_id = $1 AND revoked_at IS NULL;
+
 -- name: ResetRefreshTokens :exec
 DELETE FROM refresh_tokens;
@@ -0,0 +1,62 @@
+-- name: CreateReport :one
+INSERT INTO reports (id, created_at, chirp_id, author_id, chirp_body, reporter_id, reason, details)
+SELECT gen_random_uuid(), now(), chirps.id, chirps.user_id, chirps.body, sqlc.narg(reporter_id), sqlc.arg(reason)::text, sqlc.arg(details)::text
+FROM chirps
+WHERE chirps.id = sqlc.arg(chirp_id)
+RETURNING *;
+
+-- name: GetReport :one
+SELECT * FROM reports WHERE id = $1;
+
+-- name: GetReports :many
+SELECT * FROM reports
+WHERE status = sqlc.arg(status)
+  AND (sqlc.narg(created_at)::timestamp IS NULL OR (created_at, id) > (sqlc.narg(created_at), sqlc.narg(id)::uuid))
+ORDER BY created_at, id
+LIMIT sqlc.arg(row_limit);
+
+-- name: ClaimReport :one
+UPDATE reports SET status = 'claimed', claimed_by = sqlc.arg(moderator_id), claimed_at = now()
+WHERE id = sqlc.arg(id)
+  AND (status = 'open' OR (status = 'claimed' AND claimed_by = sqlc.arg(moderator_id)))
+RETURNING *;
+
+-- name: ResolveReport :one
+UPDATE reports SET status = 'resolved', resolved_by = sqlc.arg(moderator_id), resolved_at = now(), resolution = sqlc.arg(resolution)
+WHERE id = sqlc.arg(id) AND status = 'claimed' AND claimed_by = sqlc.arg(moderator_id)
+RETURNING *;
+
+-- name: ResolveChirpReports :many
+UPDATE reports SET status = 'resolved', resolved_by = sqlc.arg(moderator_id), resolved_at = now(), resolution = sqlc.arg(resolution)
+WHERE chirp_id = sqlc.arg(chirp_id) AND status <> 'resolved'
+RETURNING *;
+
+-- name: CreateModerationLogEntry :exec
+INSERT INTO moderation_log (id, created_at, moderator_id, report_id, action, target_user_id, target_chirp_id, note)
+VALUES (
+    gen_random_uuid(),
+    now(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+);
+
+-- name: GetModerationLog :many
+SELECT * FROM moderation_log
+WHERE (sqlc.narg(created_at)::timestamp IS NULL OR (created_at, id) < (sqlc.narg(created_at), sqlc.narg(id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: CreateUserWarning :exec
+INSERT INTO user_warnings (id, created_at, user_id, moderator_id, report_id, note)
+VALUES (
+    gen_random_uuid(),
+    now(),
+    $1,
+    $2,
+    $3,
+    $4
+);
@@ -0,0 +1,16 @@
+-- name: CreateSecurityEvent :exec
+INSERT INTO security_events (id, created_at, user_id, kind, ip_address, user_agent, details)
+VALUES (
+    gen_random_uuid(),
+    now(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+);
+
+-- name: GetSecurityEvents :many
+SELECT * FROM security_events WHERE user_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
@@ -0,0 +1,24 @@
+-- name: GetSigningKeys :many
+SELECT * FROM signing_keys
+WHERE expires_at IS NULL OR expires_at > now()
+ORDER BY active_at;
+
+-- name: LockSigningKeys :exec
+SELECT pg_advisory_xact_lock(hashtext('signing_keys'));
+
+-- name: CreateSigningKeyIfDue :execrows
+INSERT INTO signing_keys (kid, algorithm, encrypted_private_key, created_at, active_at)
+SELECT sqlc.arg(kid), sqlc.arg(algorithm), sqlc.arg(encrypted_private_key), now(), sqlc.arg(active_at)
+WHERE NOT EXISTS (
+    SELECT 1 FROM signing_keys
+    WHERE algorithm = sqlc.arg(algorithm)
+      AND created_at > sqlc.arg(due_after)
+      AND (expires_at IS NULL OR expires_at > now())
+);
+
+-- name: RetireSigningKeys :exec
+UPDATE signing_keys SET expires_at = sqlc.arg(expires_at)
+WHERE kid <> sqlc.arg(kid) AND expires_at IS NULL;
+
+-- name: DeleteExpiredSigningKeys :exec
+DELETE FROM signing_keys WHERE expires_at < now();
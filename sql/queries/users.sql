@@ -1,19 +1,64 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, username)
 VALUES (
     gen_random_uuid(),
     now(),
     now(),
     $1,
-    $2
+    $2,
+    $3
 )
 RETURNING *;
 
 -- name: GetUser :one
 SELECT * FROM users WHERE email = $1;
 
+-- name: GetUserByUsername :one
+SELECT * FROM users WHERE lower(username) = lower(sqlc.arg(username));
+
+-- name: GetUserByID :one
+SELECT * FROM users WHERE id = $1;
+
+-- name: GetPublicProfile :one
+SELECT id, created_at, username, display_name, bio, avatar_url, protected,
+    (SELECT count(*) FROM follows WHERE followee_id = users.id) AS follower_count,
+    (SELECT count(*) FROM follows WHERE follower_id = users.id) AS following_count,
+    (SELECT count(*) FROM chirps WHERE user_id = users.id AND deleted_at IS NULL AND moderation_state = 'visible') AS chirp_count
+FROM users
+WHERE lower(username) = lower(sqlc.arg(username));
+
+-- name: GetUsersByUsernames :many
+SELECT * FROM users WHERE lower(username) = ANY(sqlc.arg(usernames)::text[]);
+
 -- name: ChangeEmailPassword :exec
 UPDATE users set email = $2, hashed_password = $3 WHERE id = $1;
 
+-- name: UpdateUsername :exec
+UPDATE users SET username = $2, updated_at = now() WHERE id = $1;
+
+-- name: UpdateProfile :one
+UPDATE users SET display_name = $2, bio = $3, avatar_url = $4, protected = $5, updated_at = now() WHERE id = $1
+RETURNING *;
+
 -- name: ResetUsers :exec
 DELETE FROM users;
+
+-- name: SuspendUser :one
+UPDATE users SET suspended_at = now(), suspended_until = $2, suspension_reason = $3, suspension_hides_chirps = $4, updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: UnsuspendUser :one
+UPDATE users SET suspended_at = NULL, suspended_until = NULL, suspension_reason = '', suspension_hides_chirps = false, updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetUserRole :one
+UPDATE users SET role = $2, updated_at = now() WHERE id = $1
+RETURNING *;
+
+-- name: CountUsersWithRole :one
+SELECT count(*) FROM users WHERE role = $1;
+
+-- name: LockUsersWithRole :many
+SELECT id FROM users WHERE role = $1 ORDER BY id FOR UPDATE;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE chirp_revisions (
+    id uuid primary key,
+    chirp_id uuid not null REFERENCES chirps ON DELETE CASCADE,
+    body text not null,
+    created_at timestamp not null,
+    replaced_at timestamp not null
+);
+
+CREATE INDEX chirp_revisions_chirp_id_idx ON chirp_revisions (chirp_id, created_at);
+
+-- +goose Down
+DROP TABLE chirp_revisions;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE follows (
+    follower_id uuid not null REFERENCES users ON DELETE CASCADE,
+    followee_id uuid not null REFERENCES users ON DELETE CASCADE,
+    created_at timestamp not null,
+    primary key (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX follows_followee_id_idx ON follows (followee_id);
+
+-- +goose Down
+DROP TABLE follows;
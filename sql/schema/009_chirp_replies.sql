@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN in_reply_to uuid REFERENCES chirps ON DELETE SET NULL,
+ADD COLUMN conversation_id uuid,
+ADD COLUMN deleted_at timestamp;
+
+UPDATE chirps SET conversation_id = id;
+
+ALTER TABLE chirps
+ALTER COLUMN conversation_id SET NOT NULL;
+
+CREATE INDEX chirps_conversation_id_idx ON chirps (conversation_id, created_at);
+
+-- +goose Down
+ALTER TABLE chirps
+DROP COLUMN deleted_at,
+DROP COLUMN conversation_id,
+DROP COLUMN in_reply_to;
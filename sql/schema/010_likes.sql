@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE likes (
+    user_id uuid not null REFERENCES users ON DELETE CASCADE,
+    chirp_id uuid not null REFERENCES chirps ON DELETE CASCADE,
+    created_at timestamp not null,
+    primary key (user_id, chirp_id)
+);
+
+CREATE INDEX likes_chirp_id_idx ON likes (chirp_id);
+
+-- +goose Down
+DROP TABLE likes;
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN repost_of uuid REFERENCES chirps ON DELETE SET NULL,
+ADD COLUMN kind text not null default 'chirp';
+
+CREATE INDEX chirps_repost_of_idx ON chirps (repost_of);
+CREATE UNIQUE INDEX chirps_rechirp_unique_idx ON chirps (user_id, repost_of) WHERE kind = 'rechirp';
+
+-- +goose Down
+ALTER TABLE chirps
+DROP COLUMN kind,
+DROP COLUMN repost_of;
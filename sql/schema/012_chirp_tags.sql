@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE chirp_tags (
+    chirp_id uuid not null REFERENCES chirps ON DELETE CASCADE,
+    tag text not null,
+    created_at timestamp not null,
+    primary key (chirp_id, tag)
+);
+
+CREATE INDEX chirp_tags_tag_idx ON chirp_tags (tag, created_at);
+CREATE INDEX chirp_tags_created_at_idx ON chirp_tags (created_at);
+
+-- +goose Down
+DROP TABLE chirp_tags;
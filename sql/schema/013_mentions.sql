@@ -0,0 +1,23 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN username text;
+
+CREATE UNIQUE INDEX users_username_lower_idx ON users (lower(username));
+
+CREATE TABLE chirp_mentions (
+    chirp_id uuid not null REFERENCES chirps ON DELETE CASCADE,
+    user_id uuid not null REFERENCES users ON DELETE CASCADE,
+    start_offset int not null,
+    end_offset int not null,
+    primary key (chirp_id, start_offset)
+);
+
+CREATE INDEX chirp_mentions_user_id_idx ON chirp_mentions (user_id);
+
+-- +goose Down
+DROP TABLE chirp_mentions;
+
+DROP INDEX users_username_lower_idx;
+
+ALTER TABLE users
+DROP COLUMN username;
@@ -0,0 +1,15 @@
+-- +goose Up
+UPDATE users SET username = 'user_' || substr(replace(id::text, '-', ''), 1, 16) WHERE username IS NULL;
+
+ALTER TABLE users
+ALTER COLUMN username SET NOT NULL,
+ADD COLUMN display_name text not null default '',
+ADD COLUMN bio text not null default '',
+ADD COLUMN avatar_url text not null default '';
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN avatar_url,
+DROP COLUMN bio,
+DROP COLUMN display_name,
+ALTER COLUMN username DROP NOT NULL;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE media (
+    id uuid primary key,
+    created_at timestamp not null,
+    user_id uuid not null REFERENCES users ON DELETE CASCADE,
+    content_type text not null,
+    size_bytes bigint not null,
+    storage_key text unique not null,
+    chirp_id uuid REFERENCES chirps ON DELETE CASCADE,
+    position int not null default 0
+);
+
+CREATE INDEX media_chirp_id_idx ON media (chirp_id, position);
+
+-- +goose Down
+DROP TABLE media;
@@ -0,0 +1,24 @@
+-- +goose Up
+ALTER TABLE media
+    ADD COLUMN width int,
+    ADD COLUMN height int,
+    ADD COLUMN blurhash text;
+
+CREATE TABLE media_variants (
+    media_id uuid not null REFERENCES media ON DELETE CASCADE,
+    name text not null,
+    content_type text not null,
+    size_bytes bigint not null,
+    storage_key text unique not null,
+    width int not null,
+    height int not null,
+    primary key (media_id, name)
+);
+
+-- +goose Down
+DROP TABLE media_variants;
+
+ALTER TABLE media
+    DROP COLUMN width,
+    DROP COLUMN height,
+    DROP COLUMN blurhash;
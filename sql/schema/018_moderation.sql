@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE moderation_rules (
+    id uuid primary key,
+    created_at timestamp not null,
+    kind text not null CHECK (kind IN ('word', 'regex')),
+    pattern text not null,
+    action text not null CHECK (action IN ('mask', 'hold', 'reject')),
+    UNIQUE (kind, pattern)
+);
+
+ALTER TABLE chirps ADD COLUMN moderation_state text not null default 'visible';
+
+-- +goose Down
+ALTER TABLE chirps DROP COLUMN moderation_state;
+
+DROP TABLE moderation_rules;
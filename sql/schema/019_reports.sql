@@ -0,0 +1,48 @@
+-- +goose Up
+-- reports outlive the chirp so the queue and audit trail keep their evidence
+CREATE TABLE reports (
+    id uuid primary key,
+    created_at timestamp not null,
+    chirp_id uuid REFERENCES chirps ON DELETE SET NULL,
+    author_id uuid not null REFERENCES users ON DELETE CASCADE,
+    chirp_body text not null,
+    reporter_id uuid REFERENCES users ON DELETE SET NULL,
+    reason text not null,
+    details text not null default '',
+    status text not null default 'open' CHECK (status IN ('open', 'claimed', 'resolved')),
+    claimed_by uuid REFERENCES users ON DELETE SET NULL,
+    claimed_at timestamp,
+    resolved_by uuid REFERENCES users ON DELETE SET NULL,
+    resolved_at timestamp,
+    resolution text
+);
+
+CREATE INDEX reports_status_idx ON reports (status, created_at, id);
+CREATE UNIQUE INDEX reports_reporter_chirp_idx ON reports (chirp_id, reporter_id) WHERE reporter_id IS NOT NULL;
+
+CREATE TABLE moderation_log (
+    id uuid primary key,
+    created_at timestamp not null,
+    moderator_id uuid REFERENCES users ON DELETE SET NULL,
+    report_id uuid REFERENCES reports ON DELETE SET NULL,
+    action text not null,
+    target_user_id uuid,
+    target_chirp_id uuid,
+    note text not null default ''
+);
+
+CREATE INDEX moderation_log_created_at_idx ON moderation_log (created_at, id);
+
+CREATE TABLE user_warnings (
+    id uuid primary key,
+    created_at timestamp not null,
+    user_id uuid not null REFERENCES users ON DELETE CASCADE,
+    moderator_id uuid REFERENCES users ON DELETE SET NULL,
+    report_id uuid REFERENCES reports ON DELETE SET NULL,
+    note text not null default ''
+);
+
+-- +goose Down
+DROP TABLE user_warnings;
+DROP TABLE moderation_log;
+DROP TABLE reports;
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN suspended_at timestamp,
+ADD COLUMN suspended_until timestamp,
+ADD COLUMN suspension_reason text not null default '',
+ADD COLUMN suspension_hides_chirps boolean not null default false;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN suspension_hides_chirps,
+DROP COLUMN suspension_reason,
+DROP COLUMN suspended_until,
+DROP COLUMN suspended_at;
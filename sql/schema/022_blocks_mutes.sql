@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TABLE blocks (
+    blocker_id uuid not null REFERENCES users ON DELETE CASCADE,
+    blocked_id uuid not null REFERENCES users ON DELETE CASCADE,
+    created_at timestamp not null,
+    primary key (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX blocks_blocked_id_idx ON blocks (blocked_id);
+
+CREATE TABLE mutes (
+    muter_id uuid not null REFERENCES users ON DELETE CASCADE,
+    muted_id uuid not null REFERENCES users ON DELETE CASCADE,
+    created_at timestamp not null,
+    primary key (muter_id, muted_id),
+    CHECK (muter_id <> muted_id)
+);
+
+CREATE TABLE muted_keywords (
+    user_id uuid not null REFERENCES users ON DELETE CASCADE,
+    keyword text not null,
+    created_at timestamp not null,
+    primary key (user_id, keyword)
+);
+
+-- +goose Down
+DROP TABLE muted_keywords;
+DROP TABLE mutes;
+DROP TABLE blocks;
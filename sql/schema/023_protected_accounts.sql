@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN protected boolean not null default false;
+
+CREATE TABLE follow_requests (
+    requester_id uuid not null REFERENCES users ON DELETE CASCADE,
+    target_id uuid not null REFERENCES users ON DELETE CASCADE,
+    created_at timestamp not null,
+    primary key (requester_id, target_id),
+    CHECK (requester_id <> target_id)
+);
+
+CREATE INDEX follow_requests_target_id_idx ON follow_requests (target_id, created_at);
+
+-- +goose Down
+DROP TABLE follow_requests;
+ALTER TABLE users DROP COLUMN protected;
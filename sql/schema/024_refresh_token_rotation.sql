@@ -0,0 +1,23 @@
+-- +goose Up
+ALTER TABLE refresh_tokens ADD COLUMN family_id uuid not null default gen_random_uuid();
+ALTER TABLE refresh_tokens ALTER COLUMN family_id DROP DEFAULT;
+ALTER TABLE refresh_tokens ADD COLUMN replaced_by text;
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+
+CREATE TABLE security_events (
+    id uuid primary key,
+    created_at timestamp not null,
+    user_id uuid not null REFERENCES users ON DELETE CASCADE,
+    kind text not null,
+    ip_address text not null default '',
+    user_agent text not null default '',
+    details text not null default ''
+);
+
+CREATE INDEX security_events_user_id_idx ON security_events (user_id, created_at);
+
+-- +goose Down
+DROP TABLE security_events;
+ALTER TABLE refresh_tokens DROP COLUMN replaced_by;
+ALTER TABLE refresh_tokens DROP COLUMN family_id;
@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE refresh_tokens ADD COLUMN user_agent text not null default '';
+ALTER TABLE refresh_tokens ADD COLUMN ip_address text not null default '';
+ALTER TABLE refresh_tokens ADD COLUMN last_used_at timestamp;
+ALTER TABLE refresh_tokens ADD COLUMN session_name text not null default '';
+
+UPDATE refresh_tokens SET last_used_at = updated_at;
+ALTER TABLE refresh_tokens ALTER COLUMN last_used_at SET NOT NULL;
+
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id) WHERE revoked_at IS NULL;
+
+-- +goose Down
+DROP INDEX refresh_tokens_user_id_idx;
+ALTER TABLE refresh_tokens DROP COLUMN session_name;
+ALTER TABLE refresh_tokens DROP COLUMN last_used_at;
+ALTER TABLE refresh_tokens DROP COLUMN ip_address;
+ALTER TABLE refresh_tokens DROP COLUMN user_agent;
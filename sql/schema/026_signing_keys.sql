@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE signing_keys (
+    kid text primary key,
+    algorithm text not null,
+    encrypted_private_key bytea not null,
+    created_at timestamp not null,
+    active_at timestamp not null,
+    expires_at timestamp
+);
+
+-- +goose Down
+DROP TABLE signing_keys;
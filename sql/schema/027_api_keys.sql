@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE api_keys (
+    id uuid primary key,
+    user_id uuid not null references users(id) on delete cascade,
+    name text not null,
+    prefix text not null,
+    hashed_key text not null unique,
+    scopes text[] not null,
+    created_at timestamp not null,
+    expires_at timestamp,
+    last_used_at timestamp,
+    revoked_at timestamp
+);
+
+CREATE INDEX api_keys_user_id_idx ON api_keys (user_id) WHERE revoked_at IS NULL;
+
+-- +goose Down
+DROP TABLE api_keys;
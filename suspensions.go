@@ -0,0 +1,337 @@
+package main
+
+import (
+  "context"
+  "database/sql"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "net/http"
+  "time"
+
+  "github.com/google/uuid"
+
+  "github.com/j-wut/chirpy/internal/auth"
+  "github.com/j-wut/chirpy/internal/database"
+)
+
+// userSuspended reports whether a suspension is in force. Timed
+// suspensions lapse on their own.
+func userSuspended(user database.User) bool {
+  return user.SuspendedAt.Valid && (!user.SuspendedUntil.Valid || user.SuspendedUntil.Time.After(time.Now()))
+}
+
+// writeSuspended rejects a suspended user with a body clients can act on.
+func writeSuspended(w http.ResponseWriter, user database.User) {
+  res := struct {
+    Error          string     `json:"error"`
+    Reason         string     `json:"reason"`
+    SuspendedUntil *time.Time `json:"suspended_until"`
+  }{"account_suspended", user.SuspensionReason, nullTimePtr(user.SuspendedUntil)}
+  resStr, _ := json.Marshal(res)
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(403)
+  w.Write(resStr)
+}
+
+// tokenErrorReasons tell clients why their access token or API key was turned down, so
+// they know whether refreshing it can help.
+var tokenErrorReasons = []struct {
+  err     error
+  reason  string
+}{
+  {auth.ErrNoAuthHeader, "token_missing"},
+  {auth.ErrTokenExpired, "token_expired"},
+  {auth.ErrTokenNotYetValid, "token_not_yet_valid"},
+  {auth.ErrTokenIssuedInFuture, "token_issued_in_future"},
+  {auth.ErrTokenIssuer, "token_invalid_issuer"},
+  {auth.ErrTokenAudience, "token_invalid_audience"},
+  {auth.ErrTokenAlgorithm, "token_invalid_algorithm"},
+  {auth.ErrUnknownKey, "token_unknown_key"},
+  {auth.ErrTokenSignature, "token_invalid_signature"},
+  {auth.ErrTokenMalformed, "token_malformed"},
+  {auth.ErrAPIKeyInvalid, "api_key_invalid"},
+  {auth.ErrAPIKeyExpired, "api_key_expired"},
+}
+
+// writeInvalidToken rejects a token that failed validation.
+func writeInvalidToken(w http.ResponseWriter, err error) {
+  reason := "token_invalid"
+  for _, e := range tokenErrorReasons {
+    if errors.Is(err, e.err) {
+      reason = e.reason
+      break
+    }
+  }
+  res := struct {
+    Error string `json:"error"`
+  }{reason}
+  resStr, _ := json.Marshal(res)
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(401)
+  w.Write(resStr)
+}
+
+// writeInsufficientScope rejects a caller whose API key wasn't granted scope.
+func writeInsufficientScope(w http.ResponseWriter, scope string) {
+  res := struct {
+    Error string `json:"error"`
+    Scope string `json:"scope"`
+  }{"insufficient_scope", scope}
+  resStr, _ := json.Marshal(res)
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(403)
+  w.Write(resStr)
+}
+
+// authenticateRole checks the caller's JWT or API key, that it grants scope,
+// and that their account is in good standing, writing the error response
+// when it isn't. JWTs grant every scope.
+func (cfg *apiConfig) authenticateRole(w http.ResponseWriter, r *http.Request, scope string) (uuid.UUID, string, bool) {
+  bearer, err := auth.GetBearerToken(r.Header)
+  if err != nil {
+    writeInvalidToken(w, err)
+    return uuid.Nil, "", false
+  }
+
+  var userID uuid.UUID
+  var role string
+  scopes := auth.AllScopes
+  if auth.IsAPIKey(bearer) {
+    key, err := cfg.lookupAPIKey(r.Context(), bearer)
+    if errors.Is(err, auth.ErrAPIKeyInvalid) || errors.Is(err, auth.ErrAPIKeyExpired) {
+      writeInvalidToken(w, err)
+      return uuid.Nil, "", false
+    } else if err != nil {
+      fmt.Printf("Error retrieving API key: %s", err)
+      w.WriteHeader(500)
+      return uuid.Nil, "", false
+    }
+    userID, scopes = key.UserID, key.Scopes
+  } else {
+    userID, role, err = auth.ValidateJWTRole(bearer, cfg.keys, cfg.jwtLeeway)
+    if err != nil {
+      writeInvalidToken(w, err)
+      return uuid.Nil, "", false
+    }
+  }
+
+  if !auth.HasScope(scopes, scope) {
+    writeInsufficientScope(w, scope)
+    return uuid.Nil, "", false
+  }
+
+  // tokens outlive suspensions, so the account has to be checked every time
+  user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+  if errors.Is(err, sql.ErrNoRows) {
+    w.WriteHeader(401)
+    return uuid.Nil, "", false
+  } else if err != nil {
+    fmt.Printf("Error retrieving user: %s", err)
+    w.WriteHeader(500)
+    return uuid.Nil, "", false
+  }
+  if userSuspended(user) {
+    writeSuspended(w, user)
+    return uuid.Nil, "", false
+  }
+  // API keys don't carry a role, so theirs is always current
+  if role == "" {
+    role = user.Role
+  }
+  return userID, role, true
+}
+
+// authenticate is authenticateRole for handlers that don't care about roles.
+func (cfg *apiConfig) authenticate(w http.ResponseWriter, r *http.Request, scope string) (uuid.UUID, bool) {
+  userID, _, ok := cfg.authenticateRole(w, r, scope)
+  return userID, ok
+}
+
+// suspendUser suspends a user until the given time, or indefinitely, and
+// signs them out everywhere. Run it in a transaction so both happen.
+func suspendUser(ctx context.Context, q *database.Queries, userID uuid.UUID, until sql.NullTime, reason string, hideChirps bool) (database.User, error) {
+  user, err := q.SuspendUser(ctx, database.SuspendUserParams{
+    ID: userID,
+    SuspendedUntil: until,
+    SuspensionReason: reason,
+    SuspensionHidesChirps: hideChirps,
+  })
+  if err != nil {
+    return database.User{}, err
+  }
+  return user, q.RevokeUserRefreshTokens(ctx, userID)
+}
+
+func suspensionEnd(days int) sql.NullTime {
+  if days == 0 {
+    return sql.NullTime{}
+  }
+  return sql.NullTime{Time: time.Now().UTC().AddDate(0, 0, days), Valid: true}
+}
+
+type SuspendRequest struct {
+  Reason     string `json:"reason"`
+  // zero suspends indefinitely
+  Days       int    `json:"days"`
+  HideChirps bool   `json:"hide_chirps"`
+}
+
+type Suspension struct {
+  UserID         uuid.UUID  `json:"user_id"`
+  Suspended      bool       `json:"suspended"`
+  SuspendedAt    *time.Time `json:"suspended_at,omitempty"`
+  SuspendedUntil *time.Time `json:"suspended_until,omitempty"`
+  Reason         string     `json:"reason,omitempty"`
+  HideChirps     bool       `json:"hide_chirps"`
+}
+
+func DatabaseUserToSuspension(user database.User) Suspension {
+  return Suspension{
+    UserID: user.ID,
+    Suspended: userSuspended(user),
+    SuspendedAt: nullTimePtr(user.SuspendedAt),
+    SuspendedUntil: nullTimePtr(user.SuspendedUntil),
+    Reason: user.SuspensionReason,
+    HideChirps: user.SuspensionHidesChirps,
+  }
+}
+
+func (cfg *apiConfig) suspend(w http.ResponseWriter, r *http.Request) {
+  adminID := callerID(r.Context())
+
+  userID, err := uuid.Parse(r.PathValue("id"))
+  if err != nil {
+    w.WriteHeader(404)
+    return
+  }
+
+  decoder := json.NewDecoder(r.Body)
+  requestBody := SuspendRequest{}
+  if err = decoder.Decode(&requestBody); err != nil {
+    fmt.Printf("Error decoding parameters: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  if requestBody.Reason == "" {
+    w.WriteHeader(400)
+    w.Write([]byte("A suspension needs a reason"))
+    return
+  }
+  if requestBody.Days < 0 {
+    w.WriteHeader(400)
+    w.Write([]byte("days cannot be negative"))
+    return
+  }
+  if userID == adminID {
+    w.WriteHeader(400)
+    w.Write([]byte("You cannot suspend yourself"))
+    return
+  }
+
+  if _, err = cfg.dbQueries.GetUserByID(r.Context(), userID); errors.Is(err, sql.ErrNoRows) {
+    w.WriteHeader(404)
+    return
+  } else if err != nil {
+    fmt.Printf("Error retrieving user: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  tx, err := cfg.db.BeginTx(r.Context(), nil)
+  if err != nil {
+    fmt.Printf("Error starting transaction: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  defer tx.Rollback()
+  qtx := cfg.dbQueries.WithTx(tx)
+
+  user, err := suspendUser(r.Context(), qtx, userID, suspensionEnd(requestBody.Days), requestBody.Reason, requestBody.HideChirps)
+  if err != nil {
+    fmt.Printf("Error suspending user: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  if err = logSuspension(r.Context(), qtx, adminID, user.ID, "suspend", requestBody.Reason); err != nil {
+    fmt.Printf("Error logging suspension: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  if err = tx.Commit(); err != nil {
+    fmt.Printf("Error committing suspension: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  cfg.writeSuspension(w, user)
+}
+
+func (cfg *apiConfig) unsuspend(w http.ResponseWriter, r *http.Request) {
+  adminID := callerID(r.Context())
+
+  userID, err := uuid.Parse(r.PathValue("id"))
+  if err != nil {
+    w.WriteHeader(404)
+    return
+  }
+
+  tx, err := cfg.db.BeginTx(r.Context(), nil)
+  if err != nil {
+    fmt.Printf("Error starting transaction: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  defer tx.Rollback()
+  qtx := cfg.dbQueries.WithTx(tx)
+
+  user, err := qtx.UnsuspendUser(r.Context(), userID)
+  if errors.Is(err, sql.ErrNoRows) {
+    w.WriteHeader(404)
+    return
+  } else if err != nil {
+    fmt.Printf("Error lifting suspension: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  if err = logSuspension(r.Context(), qtx, adminID, user.ID, "unsuspend", ""); err != nil {
+    fmt.Printf("Error logging unsuspension: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  if err = tx.Commit(); err != nil {
+    fmt.Printf("Error committing unsuspension: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  cfg.writeSuspension(w, user)
+}
+
+// logSuspension records the decision in the moderation log through q, which
+// should be the transaction making the decision so neither lands alone.
+func logSuspension(ctx context.Context, q *database.Queries, adminID, userID uuid.UUID, action, note string) error {
+  return q.CreateModerationLogEntry(ctx, database.CreateModerationLogEntryParams{
+    ModeratorID: uuid.NullUUID{UUID: adminID, Valid: true},
+    Action: action,
+    TargetUserID: uuid.NullUUID{UUID: userID, Valid: true},
+    Note: note,
+  })
+}
+
+func (cfg *apiConfig) writeSuspension(w http.ResponseWriter, user database.User) {
+  resStr, err := json.Marshal(DatabaseUserToSuspension(user))
+  if err != nil {
+    fmt.Printf("Error Marshalling suspension: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+}
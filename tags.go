@@ -0,0 +1,144 @@
+package main
+
+import (
+  "context"
+  "database/sql"
+  "encoding/json"
+  "fmt"
+  "net/http"
+  "time"
+
+  "github.com/google/uuid"
+
+  "github.com/j-wut/chirpy/internal/database"
+  "github.com/j-wut/chirpy/internal/entities"
+  "github.com/j-wut/chirpy/internal/pagination"
+)
+
+const (
+  defaultTrendingWindow = 24 * time.Hour
+  maxTrendingWindow     = 7 * 24 * time.Hour
+  trendingLimit         = 10
+)
+
+type TrendingTag struct {
+  Tag           string  `json:"tag"`
+  RecentCount   int64   `json:"recent_count"`
+  PreviousCount int64   `json:"previous_count"`
+  Growth        float64 `json:"growth"`
+}
+
+// saveTags indexes the hashtags in a chirp's body. Callers editing a chirp
+// should clear its old tags first. Tags are dated by the chirp, so an edit
+// doesn't count towards trending again.
+func saveTags(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+  // held chirps are indexed once they're released
+  if chirp.ModerationState != chirpVisible {
+    return nil
+  }
+  tags := entities.Hashtags(chirp.Body)
+  if len(tags) == 0 {
+    return nil
+  }
+  return q.CreateChirpTags(ctx, database.CreateChirpTagsParams{ChirpID: chirp.ID, Tags: tags})
+}
+
+func (cfg *apiConfig) getTagChirps(w http.ResponseWriter, r *http.Request) {
+  tag := entities.NormalizeHashtag(r.PathValue("tag"))
+  if tag == "" {
+    w.WriteHeader(404)
+    return
+  }
+
+  query := r.URL.Query()
+  limit, err := pagination.ParseLimit(query.Get("limit"))
+  if err != nil {
+    w.WriteHeader(400)
+    w.Write([]byte(err.Error()))
+    return
+  }
+
+  viewer := cfg.optionalUserID(r)
+  params := database.GetTagChirpsParams{Tag: tag, ViewerID: viewer, RowLimit: limit + 1}
+  if after := query.Get("after"); after != "" {
+    cursor, err := pagination.DecodeCursor(after)
+    if err != nil {
+      w.WriteHeader(400)
+      w.Write([]byte(err.Error()))
+      return
+    }
+    params.CreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+    params.ID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+  }
+
+  chirps, err := cfg.dbQueries.GetTagChirps(r.Context(), params)
+  if err != nil {
+    fmt.Printf("Error retrieving tag chirps: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  page := ChirpPage{}
+  if len(chirps) > int(limit) {
+    chirps = chirps[:limit]
+    page.NextCursor = chirpCursor(chirps[len(chirps)-1])
+  }
+  page.Chirps = DatabaseChirpsToReadable(chirps)
+
+  if err = cfg.decorateChirps(r.Context(), viewer, page.Chirps); err != nil {
+    fmt.Printf("Error decorating chirps: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  resStr, err := json.Marshal(page)
+  if err != nil {
+    fmt.Printf("Error Marshalling chirps: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
+
+// getTrendingTags ranks tags by how much more they were used in the last
+// window than in the window before it.
+func (cfg *apiConfig) getTrendingTags(w http.ResponseWriter, r *http.Request) {
+  window := defaultTrendingWindow
+  if param := r.URL.Query().Get("window"); param != "" {
+    parsed, err := time.ParseDuration(param)
+    if err != nil || parsed < time.Minute || parsed > maxTrendingWindow {
+      w.WriteHeader(400)
+      w.Write([]byte("window must be a duration between 1m and 168h"))
+      return
+    }
+    window = parsed
+  }
+
+  rows, err := cfg.dbQueries.GetTrendingTags(r.Context(), database.GetTrendingTagsParams{WindowSeconds: int32(window.Seconds()), RowLimit: trendingLimit})
+  if err != nil {
+    fmt.Printf("Error retrieving trending tags: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  trending := []TrendingTag{}
+  for _, row := range rows {
+    trending = append(trending, TrendingTag{row.Tag, row.RecentCount, row.PreviousCount, row.Growth})
+  }
+
+  resStr, err := json.Marshal(trending)
+  if err != nil {
+    fmt.Printf("Error Marshalling trending tags: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
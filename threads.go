@@ -0,0 +1,167 @@
+package main
+
+import (
+  "context"
+  "database/sql"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "net/http"
+
+  "github.com/google/uuid"
+
+  "github.com/j-wut/chirpy/internal/database"
+)
+
+type ThreadNode struct {
+  ReadableChirp
+  Depth    int           `json:"depth"`
+  Replies  []*ThreadNode `json:"replies"`
+}
+
+// buildThread arranges a conversation, ordered oldest first, into a reply
+// tree. If the root is gone (its author was removed) the oldest chirp stands
+// in for it, and replies whose parent is missing are attached to the root.
+func buildThread(chirps []ReadableChirp) *ThreadNode {
+  if len(chirps) == 0 {
+    return nil
+  }
+
+  nodes := map[uuid.UUID]*ThreadNode{}
+  var root *ThreadNode
+  for _, chirp := range chirps {
+    node := &ThreadNode{ReadableChirp: chirp, Replies: []*ThreadNode{}}
+    nodes[chirp.ID] = node
+    if chirp.ID == chirp.ConversationID {
+      root = node
+    }
+  }
+  if root == nil {
+    root = nodes[chirps[0].ID]
+  }
+
+  for _, chirp := range chirps {
+    if chirp.ID == root.ID {
+      continue
+    }
+    parent, ok := nodes[chirp.InReplyTo.UUID]
+    if !chirp.InReplyTo.Valid || !ok {
+      parent = root
+    }
+    parent.Replies = append(parent.Replies, nodes[chirp.ID])
+  }
+
+  var setDepth func(node *ThreadNode, depth int)
+  setDepth = func(node *ThreadNode, depth int) {
+    node.Depth = depth
+    for _, reply := range node.Replies {
+      setDepth(reply, depth+1)
+    }
+  }
+  setDepth(root, 0)
+  return root
+}
+
+// threadChirps prepares a conversation for viewer. Chirps they can't see,
+// including those by users blocked either way and protected authors they
+// don't follow, become tombstones, which hold their place in the thread like
+// deleted ones and like them are never decorated.
+func threadChirps(conversation []database.Chirp, viewer uuid.NullUUID, blocked, hidden map[uuid.UUID]bool) []ReadableChirp {
+  readable := make([]ReadableChirp, 0, len(conversation))
+  for _, chirp := range conversation {
+    if !chirpVisibleTo(chirp, viewer) || blocked[chirp.UserID] || hidden[chirp.UserID] {
+      chirp.Body = ""
+      chirp.DeletedAt = sql.NullTime{Time: chirp.UpdatedAt, Valid: true}
+    }
+    readable = append(readable, DatabaseChirpToReadable(chirp))
+  }
+  return readable
+}
+
+func (cfg *apiConfig) getThread(w http.ResponseWriter, r *http.Request) {
+  chirpID, err := uuid.Parse(r.PathValue("id"))
+  if err != nil {
+    w.WriteHeader(404)
+    return
+  }
+
+  chirp, err := cfg.dbQueries.GetChirp(r.Context(), chirpID)
+  viewer := cfg.optionalUserID(r)
+  if errors.Is(err, sql.ErrNoRows) || (err == nil && !chirpVisibleTo(chirp, viewer)) {
+    w.WriteHeader(404)
+    return
+  } else if err != nil {
+    fmt.Printf("Error retrieving chirp: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  blocked, err := cfg.blockedUsers(r.Context(), viewer)
+  if err != nil {
+    fmt.Printf("Error retrieving blocks: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  conversation, err := cfg.dbQueries.GetConversation(r.Context(), chirp.ConversationID)
+  if err != nil {
+    fmt.Printf("Error retrieving conversation: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  authorIDs := []uuid.UUID{chirp.UserID}
+  for _, c := range conversation {
+    authorIDs = append(authorIDs, c.UserID)
+  }
+  hidden, err := cfg.hiddenAuthors(r.Context(), viewer, authorIDs)
+  if err != nil {
+    fmt.Printf("Error checking authors: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+  if blocked[chirp.UserID] || hidden[chirp.UserID] {
+    w.WriteHeader(404)
+    return
+  }
+
+  readable := threadChirps(conversation, viewer, blocked, hidden)
+  if err = cfg.decorateChirps(r.Context(), viewer, readable); err != nil {
+    fmt.Printf("Error decorating chirps: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  root := buildThread(readable)
+  if root == nil {
+    w.WriteHeader(404)
+    return
+  }
+
+  resStr, err := json.Marshal(root)
+  if err != nil {
+    fmt.Printf("Error Marshalling thread: %s", err)
+    w.WriteHeader(500)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(200)
+  w.Write(resStr)
+  return
+}
+
+// tombstoneChirp blanks out a chirp that still has replies so the thread
+// keeps its shape after the author deletes it.
+func tombstoneChirp(ctx context.Context, q *database.Queries, chirpID uuid.UUID) error {
+  if err := q.TombstoneChirp(ctx, chirpID); err != nil {
+    return err
+  }
+  if err := q.DeleteChirpRevisions(ctx, chirpID); err != nil {
+    return err
+  }
+  if err := q.DeleteChirpTags(ctx, chirpID); err != nil {
+    return err
+  }
+  return q.DeleteChirpMentions(ctx, chirpID)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+  "context"
+  "testing"
+  "time"
+
+  "github.com/google/uuid"
+
+  "github.com/j-wut/chirpy/internal/database"
+)
+
+// testConversation is a visible root with a held quote-reply under it.
+func testConversation() (database.Chirp, database.Chirp) {
+  now := time.Now()
+  root := database.Chirp{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Body: "what do you think?", UserID: uuid.New(), Kind: chirpKindChirp, ModerationState: chirpVisible}
+  root.ConversationID = root.ID
+  held := database.Chirp{
+    ID: uuid.New(),
+    CreatedAt: now.Add(time.Minute),
+    UpdatedAt: now.Add(time.Minute),
+    Body: "@someone look at this",
+    UserID: uuid.New(),
+    InReplyTo: uuid.NullUUID{UUID: root.ID, Valid: true},
+    ConversationID: root.ID,
+    RepostOf: uuid.NullUUID{UUID: uuid.New(), Valid: true},
+    Kind: chirpKindQuote,
+    ModerationState: chirpHeld,
+  }
+  return root, held
+}
+
+func TestThreadTombstonesHeldReplies(t *testing.T) {
+  root, held := testConversation()
+  viewer := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+  readable := threadChirps([]database.Chirp{root, held}, viewer, nil, nil)
+
+  // there's no database behind cfg, so this only works if the tombstone is
+  // never looked up
+  cfg := &apiConfig{}
+  if err := cfg.decorateChirps(context.Background(), viewer, readable[1:]); err != nil {
+    t.Fatalf("error decorating tombstone: %v", err)
+  }
+
+  thread := buildThread(readable)
+  if thread.ID != root.ID || len(thread.Replies) != 1 {
+    t.Fatalf("incorrect thread %+v", thread)
+  }
+  reply := thread.Replies[0]
+  if reply.ID != held.ID || !reply.Deleted || reply.Body != "" || reply.UserID != uuid.Nil || reply.Held {
+    t.Errorf("expected a tombstone, got %+v", reply.ReadableChirp)
+  }
+  if reply.RepostOf.Valid || reply.Original != nil || len(reply.Entities) != 0 || len(reply.Media) != 0 || reply.LikeCount != 0 {
+    t.Errorf("expected the tombstone to carry nothing, got %+v", reply.ReadableChirp)
+  }
+}
+
+func TestThreadShowsAuthorTheirHeldReply(t *testing.T) {
+  root, held := testConversation()
+  readable := threadChirps([]database.Chirp{root, held}, uuid.NullUUID{UUID: held.UserID, Valid: true}, nil, nil)
+
+  reply := buildThread(readable).Replies[0]
+  if reply.Deleted || reply.Body != held.Body || !reply.Held || reply.RepostOf != held.RepostOf {
+    t.Errorf("expected the author to see their held reply, got %+v", reply.ReadableChirp)
+  }
+}
+
+func TestThreadTombstonesBlockedAuthors(t *testing.T) {
+  root, reply := testConversation()
+  reply.ModerationState = chirpVisible
+  viewer := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+  readable := threadChirps([]database.Chirp{root, reply}, viewer, map[uuid.UUID]bool{reply.UserID: true}, nil)
+
+  tombstone := buildThread(readable).Replies[0]
+  if !tombstone.Deleted || tombstone.Body != "" || tombstone.RepostOf.Valid {
+    t.Errorf("expected a tombstone for a blocked author, got %+v", tombstone.ReadableChirp)
+  }
+}
+
+func TestThreadTombstonesProtectedRepliesForNonFollowers(t *testing.T) {
+  root, reply := testConversation()
+  reply.ModerationState = chirpVisible
+  viewer := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+  readable := threadChirps([]database.Chirp{root, reply}, viewer, nil, map[uuid.UUID]bool{reply.UserID: true})
+
+  cfg := &apiConfig{}
+  if err := cfg.decorateChirps(context.Background(), viewer, readable[1:]); err != nil {
+    t.Fatalf("error decorating tombstone: %v", err)
+  }
+
+  tombstone := buildThread(readable).Replies[0]
+  if !tombstone.Deleted || tombstone.Body != "" || tombstone.UserID != uuid.Nil {
+    t.Errorf("expected a tombstone for a protected author, got %+v", tombstone.ReadableChirp)
+  }
+  if tombstone.RepostOf.Valid || tombstone.Original != nil || len(tombstone.Entities) != 0 || len(tombstone.Media) != 0 {
+    t.Errorf("expected the tombstone to carry nothing, got %+v", tombstone.ReadableChirp)
+  }
+}